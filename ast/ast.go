@@ -3,71 +3,98 @@ package ast
 import (
 	"errors"
 	"go/ast"
-	"go/token"
 	"go/types"
 	"log"
 
-	"github.com/go-toolsmith/astcopy"
-
 	"golang.org/x/tools/go/ast/astutil"
 
 	"golang.org/x/tools/go/packages"
-
-	"github.com/mpppk/gollup/util"
 )
 
-func NewPackagesFromPackageNames(packageNames []string) (*Packages, *token.FileSet, error) {
-	fset := token.NewFileSet()
-	config := &packages.Config{
-		Mode: packages.NeedCompiledGoFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo | packages.LoadAllSyntax,
-		Fset: fset,
-	}
-	pkgs, err := packages.Load(config, packageNames...)
+// ExtractObjectsFromFuncDeclRecursive は指定した関数から到達可能なobjectを返す
+// インターフェースのメソッド呼び出しについては、到達可能な型のうちそのインターフェースを実装している型のメソッドを不動点に達するまで辿る
+// wellKnownInterfacesのメソッドは、その型の値がインターフェース型に変換された場合に必要とみなす
+func ExtractObjectsFromFuncDeclRecursive(pkgs *Packages, f *types.Func, objects []types.Object, wellKnownInterfaces []*types.Named) ([]types.Object, error) {
+	objects, err := extractObjectsFromFuncDeclRecursive(pkgs, f, objects, wellKnownInterfaces)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	if packages.PrintErrors(pkgs) > 0 {
-		return nil, nil, errors.New("error occurred in NewPackagesFromPackageNames")
+	for {
+		objects = appendTypeDependencies(pkgs, objects)
+		var funcs []*types.Func
+		objects, funcs = appendValueDependencies(pkgs, objects)
+		funcs = append(funcs, findImplementedMethods(objects)...)
+		if len(funcs) == 0 {
+			return objects, nil
+		}
+		for _, f := range funcs {
+			if _, ok := findObject(objects, f); ok {
+				continue
+			}
+			objs, err := extractObjectsFromFuncDeclRecursive(pkgs, f, objects, wellKnownInterfaces)
+			if err != nil {
+				return nil, err
+			}
+			objects = objs
+		}
 	}
-	return NewPackages(pkgs), fset, nil
 }
 
-func newMergedFileFromPackageInfo(files []*ast.File) *ast.File {
-	importDecl := mergeImportDecls(files)
+// ExtractReachableObjects はtargetPackageのtargetFuncと、パッケージの初期化時に呼び出されるinit関数から到達可能なobjectを返す
+// バンドル対象のパッケージを初期化する順に並べたものも合わせて返す
+func (p *Packages) ExtractReachableObjects(targetPackage, targetFunc string, wellKnownInterfaceNames []string) ([]types.Object, []*packages.Package, error) {
+	pkg, ok := p.FindPkgByName(targetPackage)
+	if !ok {
+		return nil, nil, errors.New("specified packages does not found: " + targetPackage)
+	}
+	f, ok := pkg.Types.Scope().Lookup(targetFunc).(*types.Func)
+	if !ok {
+		return nil, nil, errors.New("target is not func: " + targetPackage + "." + targetFunc)
+	}
+	wellKnownInterfaces, err := p.LookupInterfaces(wellKnownInterfaceNames)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	var imports []*ast.ImportSpec
-	for _, file := range files {
-		imports = append(imports, file.Imports...)
+	objects, err := ExtractObjectsFromFuncDeclRecursive(p, f, []types.Object{}, wellKnownInterfaces)
+	if err != nil {
+		return nil, nil, err
 	}
-	return &ast.File{
-		Name: &ast.Ident{
-			Name: "main",
-		},
-		Decls:      []ast.Decl{importDecl},
-		Scope:      nil,
-		Imports:    imports,
-		Unresolved: nil,
-		Comments:   nil,
+	// init関数はエントリポイントから参照されていなくても呼び出される
+	initOrder := p.InitOrder(pkg)
+	for _, initFunc := range InitFuncs(initOrder) {
+		objects, err = ExtractObjectsFromFuncDeclRecursive(p, initFunc, objects, wellKnownInterfaces)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
+	return objects, initOrder, nil
 }
 
-func ExtractObjectsFromFuncDeclRecursive(pkgs map[string]*packages.Package, f *types.Func, objects []types.Object) ([]types.Object, error) {
+func extractObjectsFromFuncDeclRecursive(pkgs *Packages, f *types.Func, objects []types.Object, wellKnownInterfaces []*types.Named) ([]types.Object, error) {
+	// インターフェースのメソッドは宣言を持たないので、実装の探索はfindImplementedMethodsで行う
+	if isInterfaceMethod(f) {
+		return append(objects, f), nil
+	}
+
 	log.Println("searching objects from func", f.Pkg().Name()+"."+f.Name())
-	pkg := pkgs[f.Pkg().Path()]
+	pkg := pkgs.getPkg(f.Pkg().Path())
 	if pkg == nil {
 		return nil, errors.New("specified function is not found in pkgs: " + f.Name())
 	}
-	funcDecl := findFuncDeclByFuncType(pkg.Syntax, f)
-	if funcDecl == nil {
+	funcDecl, ok := pkgs.FindDeclByObject(f).(*ast.FuncDecl)
+	if !ok {
 		return nil, errors.New("specified function is not found: " + f.Name())
 	}
 
-	calledFuncs := extractCalledFuncsFromFuncDecl(pkg.TypesInfo, funcDecl)
-	newObjects := extractNonStandardObjectFromFuncDecl(pkg.TypesInfo, funcDecl)
+	referencedFuncs := extractReferencedFuncsFromFuncDecl(pkg.TypesInfo, funcDecl)
+	referencedFuncs = append(referencedFuncs, pkgs.extractStdInterfaceMethodsFromFuncDecl(pkg.TypesInfo, funcDecl, wellKnownInterfaces)...)
+	newObjects := pkgs.extractNonStandardObjectFromFuncDecl(pkg.TypesInfo, funcDecl)
 	objects = append(objects, newObjects...)
 	objects = append(objects, f)
-	for _, f2 := range calledFuncs {
-		if !util.HasPkg(f2) || util.IsStandardPackage(f2.Pkg().Path()) {
+	for _, f2 := range referencedFuncs {
+		// 標準パッケージのインターフェースのメソッドは、lib内の型によって実装されている可能性があるため辿る
+		if !isInterfaceMethod(f2) && !pkgs.isBundledObject(f2) {
 			continue
 		}
 
@@ -76,7 +103,7 @@ func ExtractObjectsFromFuncDeclRecursive(pkgs map[string]*packages.Package, f *t
 			continue
 		}
 
-		objs, err := ExtractObjectsFromFuncDeclRecursive(pkgs, f2, objects)
+		objs, err := extractObjectsFromFuncDeclRecursive(pkgs, f2, objects, wellKnownInterfaces)
 		if err != nil {
 			return nil, err
 		}
@@ -86,253 +113,323 @@ func ExtractObjectsFromFuncDeclRecursive(pkgs map[string]*packages.Package, f *t
 	return objects, nil
 }
 
-// extractCalledFuncsFromFuncDecl は指定したパッケージの指定したfuncDecl内で呼び出されている関数を返す
-func extractCalledFuncsFromFuncDecl(info *types.Info, targetFuncDecl *ast.FuncDecl) (funcs []*types.Func) {
-	ast.Inspect(targetFuncDecl, func(node ast.Node) bool {
-		if callExpr, ok := node.(*ast.CallExpr); ok {
-			if f := callExprToFunc(info, callExpr); f != nil && f.Name() != targetFuncDecl.Name.Name {
-				funcs = append(funcs, f)
-			}
+// appendTypeDependencies はobjects中の型の宣言から参照されている型や定数を、新たなobjectが見つからなくなるまでobjectsに追加して返す
+// 構造体のフィールド(埋め込みを含む)、配列の長さ、map/chan/funcの型、インターフェースのメソッドのシグネチャ、元となる型などを辿る
+func appendTypeDependencies(pkgs *Packages, objects []types.Object) []types.Object {
+	for i := 0; i < len(objects); i++ {
+		typeName, ok := objects[i].(*types.TypeName)
+		if !ok {
+			continue
 		}
-		return true
-	})
-	return funcs
+		typeSpec, ok := pkgs.findTypeSpecByObject(typeName)
+		if !ok {
+			continue
+		}
+		pkg := pkgs.getPkg(typeName.Pkg().Path())
+		ast.Inspect(typeSpec, func(node ast.Node) bool {
+			ident, ok := node.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			obj := pkg.TypesInfo.Uses[ident]
+			if !pkgs.isBundledObject(obj) {
+				return true
+			}
+			switch t := obj.(type) {
+			case *types.Const:
+			case *types.TypeName:
+				if _, ok := t.Type().(*types.TypeParam); ok {
+					return true
+				}
+			default:
+				return true
+			}
+			if _, ok := findObject(objects, obj); !ok {
+				objects = append(objects, obj)
+			}
+			return true
+		})
+	}
+	return objects
 }
 
-// extractStructFromFuncDecl は指定したパッケージの指定したfuncDecl内で呼び出されている関数から参照されている型名を返す
-func extractNonStandardObjectFromFuncDecl(info *types.Info, targetFuncDecl *ast.FuncDecl) (objects []types.Object) {
-	ast.Inspect(targetFuncDecl, func(node ast.Node) bool {
-		ident, ok := node.(*ast.Ident)
+// appendValueDependencies はobjects中のパッケージレベルの変数や定数の宣言(型と初期化式)から参照されている型・定数・変数を、
+// 新たなobjectが見つからなくなるまでobjectsに追加して返す。参照されている関数のうちobjectsに含まれていないものは別途返す
+func appendValueDependencies(pkgs *Packages, objects []types.Object) ([]types.Object, []*types.Func) {
+	var funcs []*types.Func
+	for i := 0; i < len(objects); i++ {
+		switch objects[i].(type) {
+		case *types.Const, *types.Var:
+		default:
+			continue
+		}
+		if !pkgs.isPackageLevelObject(objects[i]) {
+			continue
+		}
+		valueSpecs, ok := pkgs.findValueSpecsByObject(objects[i])
 		if !ok {
-			return true
+			continue
 		}
-		obj := info.ObjectOf(ident)
-		if !util.HasPkg(obj) || util.IsStandardPackage(obj.Pkg().Path()) {
-			return true
+		pkg := pkgs.getPkg(objects[i].Pkg().Path())
+		var nodes []ast.Node
+		for _, valueSpec := range valueSpecs {
+			nodes = append(nodes, valueSpecDependencyNodes(valueSpec, objects[i])...)
 		}
-		switch obj.(type) {
-		case *types.Const, *types.Var, *types.TypeName:
-			objects = append(objects, obj)
+		for _, n := range nodes {
+			ast.Inspect(n, func(node ast.Node) bool {
+				ident, ok := node.(*ast.Ident)
+				if !ok {
+					return true
+				}
+				switch obj := pkg.TypesInfo.Uses[ident].(type) {
+				case *types.Func:
+					if !pkgs.isBundledObject(obj) {
+						return true
+					}
+					if _, ok := findObject(objects, obj.Origin()); !ok {
+						funcs = append(funcs, obj.Origin())
+					}
+				case *types.Const, *types.Var, *types.TypeName:
+					if !pkgs.isPackageLevelObject(obj) {
+						return true
+					}
+					if _, ok := findObject(objects, obj); !ok {
+						objects = append(objects, obj)
+					}
+				}
+				return true
+			})
 		}
-		return true
-	})
-	return
+	}
+	return objects, funcs
 }
 
-func callExprToFunc(info *types.Info, callExpr *ast.CallExpr) *types.Func {
-	switch fun := callExpr.Fun.(type) {
-	case *ast.Ident:
-		obj := info.ObjectOf(fun)
-		tFunc, ok := obj.(*types.Func)
-		if !ok {
-			return nil
+// valueSpecDependencyNodes はValueSpecのうち、objectの値を決めるために必要なnodeを返します。
+// 名前と値が1対1で対応する変数の場合は、型とobjectに対応する値だけを返します。
+func valueSpecDependencyNodes(valueSpec *ast.ValueSpec, object types.Object) []ast.Node {
+	if _, ok := object.(*types.Var); !ok || len(valueSpec.Values) != len(valueSpec.Names) {
+		return []ast.Node{valueSpec}
+	}
+	var nodes []ast.Node
+	if valueSpec.Type != nil {
+		nodes = append(nodes, valueSpec.Type)
+	}
+	for i, name := range valueSpec.Names {
+		if name.Name == object.Name() {
+			nodes = append(nodes, valueSpec.Values[i])
+		}
+	}
+	return nodes
+}
+
+// findImplementedMethods は呼び出されているインターフェースのメソッドについて、
+// objects中の型のうちそのインターフェースを実装している型のメソッドで、まだobjectsに含まれていないものを返す
+func findImplementedMethods(objects []types.Object) (methods []*types.Func) {
+	for _, object := range objects {
+		f, ok := object.(*types.Func)
+		if !ok || !isInterfaceMethod(f) {
+			continue
 		}
-		if tFunc.Pkg() != nil {
-			return tFunc
+		recvType := f.Type().(*types.Signature).Recv().Type()
+		iface := recvType.Underlying().(*types.Interface)
+		for _, object := range objects {
+			typeName, ok := object.(*types.TypeName)
+			if !ok || types.IsInterface(typeName.Type()) {
+				continue
+			}
+			method, ok := findImplementedMethod(typeName.Type(), iface, f, isGeneric(recvType) || isGeneric(typeName.Type()))
+			if !ok {
+				continue
+			}
+			if _, ok := findObject(objects, method); ok {
+				continue
+			}
+			if _, ok := findObject(funcsToObjects(methods), method); ok {
+				continue
+			}
+			methods = append(methods, method)
 		}
-		return nil
-	case *ast.SelectorExpr:
-		obj := info.ObjectOf(fun.Sel)
-		tFunc, ok := obj.(*types.Func)
-		if !ok {
-			return nil
+	}
+	return
+}
+
+// findImplementedMethod は型tがifaceを実装している場合に、ifaceのメソッドfに対応するtのメソッドを返す
+// インスタンス化されていないジェネリクスの型同士は型パラメータが異なりtypes.Implementsで判定できないため、genericの場合はメソッド名で判定する
+func findImplementedMethod(t types.Type, iface *types.Interface, f *types.Func, generic bool) (*types.Func, bool) {
+	if generic {
+		if !hasMethodsOf(t, iface) {
+			return nil, false
 		}
-		return tFunc
+	} else if !types.Implements(t, iface) && !types.Implements(types.NewPointer(t), iface) {
+		return nil, false
+	}
+	obj, _, _ := types.LookupFieldOrMethod(t, true, f.Pkg(), f.Name())
+	method, ok := obj.(*types.Func)
+	if !ok || isInterfaceMethod(method) {
+		return nil, false
 	}
-	return nil
+	return method.Origin(), true
 }
 
-func renameExternalPackageConst(funcDecl *ast.FuncDecl, pkg *packages.Package) {
-	astutil.Apply(funcDecl, func(cursor *astutil.Cursor) bool {
-		ident, ok := cursor.Node().(*ast.Ident)
+// hasMethodsOf はtもしくは*tが、ifaceの全てのメソッドと同じ名前のメソッドを持っていればtrueを返す
+func hasMethodsOf(t types.Type, iface *types.Interface) bool {
+	for i := 0; i < iface.NumMethods(); i++ {
+		m := iface.Method(i)
+		obj, _, _ := types.LookupFieldOrMethod(t, true, m.Pkg(), m.Name())
+		if _, ok := obj.(*types.Func); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// isGeneric はtが型パラメータを持つ名前付きの型であればtrueを返す
+func isGeneric(t types.Type) bool {
+	named, ok := types.Unalias(t).(*types.Named)
+	return ok && named.TypeParams().Len() > 0
+}
+
+func isInterfaceMethod(f *types.Func) bool {
+	recv := f.Type().(*types.Signature).Recv()
+	return recv != nil && types.IsInterface(recv.Type())
+}
+
+// extractReferencedFuncsFromFuncDecl は指定したパッケージの指定したfuncDecl内で参照されている関数を返す
+// 呼び出しだけでなく、関数値・メソッド値・メソッド式として参照されている関数も含む
+func extractReferencedFuncsFromFuncDecl(info *types.Info, targetFuncDecl *ast.FuncDecl) (funcs []*types.Func) {
+	ast.Inspect(targetFuncDecl, func(node ast.Node) bool {
+		ident, ok := node.(*ast.Ident)
 		if !ok {
 			return true
 		}
-		switch t := pkg.TypesInfo.ObjectOf(ident).(type) {
-		case *types.Const:
-			ident.Name = renameFunc(pkg.Types, t.Name())
+		if f, ok := info.Uses[ident].(*types.Func); ok && (f.Pkg() != nil || isInterfaceMethod(f)) {
+			// インスタンス化されたジェネリクスの型のメソッドは、宣言されている元のメソッドに置き換える
+			funcs = append(funcs, f.Origin())
 		}
-
 		return true
-	}, nil)
+	})
+	return funcs
 }
 
-func renameExternalPackageFunction(funcDecl *ast.FuncDecl, object types.Object, pkg *packages.Package) {
-	astutil.Apply(funcDecl, func(cursor *astutil.Cursor) bool {
-		if callExpr, ok := cursor.Node().(*ast.CallExpr); ok {
-			if newCallExpr := removePackageFromCallExpr(callExpr, pkg); newCallExpr != nil {
-				cursor.Replace(newCallExpr)
-			}
+// extractStructFromFuncDecl は指定したパッケージの指定したfuncDecl内で呼び出されている関数から参照されている型名を返す
+func (p *Packages) extractNonStandardObjectFromFuncDecl(info *types.Info, targetFuncDecl *ast.FuncDecl) (objects []types.Object) {
+	ast.Inspect(targetFuncDecl, func(node ast.Node) bool {
+		ident, ok := node.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		obj := info.ObjectOf(ident)
+		if !p.isBundledObject(obj) {
+			return true
 		}
-		if selectorExpr, ok := cursor.Node().(*ast.SelectorExpr); ok {
-			if newIdent := removePackageFromSelectorExpr(selectorExpr, pkg); newIdent != nil {
-				cursor.Replace(newIdent)
+		switch t := obj.(type) {
+		case *types.Const, *types.Var:
+			objects = append(objects, obj)
+		case *types.TypeName:
+			// 型パラメータは関数や型の宣言の一部なので対象外
+			if _, ok := t.Type().(*types.TypeParam); ok {
+				return true
 			}
+			objects = append(objects, obj)
 		}
-
 		return true
-	}, nil)
+	})
+	return
+}
+
+func (p *Program) renameExternalPackageFunction(funcDecl *ast.FuncDecl, object types.Object, pkg *packages.Package) {
+	p.renameReferences(funcDecl, pkg)
 
 	// 構造体のメソッドはrenameしない
 	if funcDecl.Recv == nil {
-		funcDecl.Name = ast.NewIdent(renameFunc(object.Pkg(), funcDecl.Name.Name))
+		funcDecl.Name = ast.NewIdent(p.Names.Of(object))
 	}
-
-	renameFuncDeclParams(funcDecl, pkg)
-	renameFuncDeclResults(funcDecl, pkg)
 }
 
-// 他ライブラリの構造体などを引数に取っていればrename
-func renameFuncDeclParams(funcDecl *ast.FuncDecl, pkg *packages.Package) {
-	if funcDecl.Type.Params == nil {
-		return
-	}
-	for i, result := range funcDecl.Type.Params.List {
-		switch t := result.Type.(type) {
+// renameReferences はnode中でバンドル対象のパッケージレベルの関数・型・定数・変数を参照している箇所を、バンドル後の名前にrenameします。破壊的メソッドです。
+// 参照先はTypesInfo.Usesから解決するため、型・式・文のどの位置に現れても同じ名前になります。パッケージ名で修飾された参照は識別子に置き換えます。
+func (p *Program) renameReferences(node ast.Node, pkg *packages.Package) {
+	astutil.Apply(node, func(cursor *astutil.Cursor) bool {
+		switch n := cursor.Node().(type) {
 		case *ast.SelectorExpr:
-			obj := pkg.TypesInfo.ObjectOf(t.Sel)
-			if !util.HasPkg(obj) || util.IsStandardPackage(obj.Pkg().Path()) {
-				continue
-			}
-			funcDecl.Type.Params.List[i].Type = ast.NewIdent(t.Sel.Name)
-		case *ast.StarExpr:
-			selExpr, ok := t.X.(*ast.SelectorExpr)
+			x, ok := n.X.(*ast.Ident)
 			if !ok {
-				continue
+				return true
 			}
-			obj := pkg.TypesInfo.ObjectOf(selExpr.Sel)
-			if !util.HasPkg(obj) || util.IsStandardPackage(obj.Pkg().Path()) {
-				continue
-			}
-			funcDecl.Type.Params.List[i].Type = &ast.StarExpr{
-				X: ast.NewIdent(selExpr.Sel.Name),
-			}
-		}
-	}
-}
-
-// 他ライブラリの構造体などが戻り値であればrename
-func renameFuncDeclResults(funcDecl *ast.FuncDecl, pkg *packages.Package) {
-	if funcDecl.Type.Results == nil {
-		return
-	}
-	for i, result := range funcDecl.Type.Results.List {
-		switch t := result.Type.(type) {
-		case *ast.SelectorExpr:
-			ident, ok := result.Type.(*ast.Ident)
+			pkgName, ok := pkg.TypesInfo.Uses[x].(*types.PkgName)
 			if !ok {
-				continue
+				return true
 			}
-			obj := pkg.TypesInfo.ObjectOf(ident)
-			if !util.HasPkg(obj) || util.IsStandardPackage(obj.Pkg().Path()) {
-				continue
+			// バンドルされないパッケージはimportで付けた名前で参照する
+			if !p.Packages.isBundled(pkgName.Imported().Path()) {
+				x.Name = p.imports.names[pkgName.Imported().Path()]
+				return false
 			}
-			funcDecl.Type.Results.List[i].Type = ast.NewIdent(t.Sel.Name)
-		case *ast.StarExpr:
-			selExpr, ok := t.X.(*ast.SelectorExpr)
-			if !ok {
-				continue
-			}
-			ident, ok := result.Type.(*ast.Ident)
-			if !ok {
-				continue
+			if obj, ok := p.Packages.packageLevelObjectOf(pkg.TypesInfo.Uses[n.Sel]); ok {
+				cursor.Replace(ast.NewIdent(p.Names.Of(obj)))
 			}
-			obj := pkg.TypesInfo.ObjectOf(ident)
-			if !util.HasPkg(obj) || util.IsStandardPackage(obj.Pkg().Path()) {
-				continue
+		case *ast.Ident:
+			obj := pkg.TypesInfo.Uses[n]
+			// ドットインポートされたバンドルされないパッケージのobjectは、パッケージ名で修飾する
+			if p.Packages.isDotImportedObject(obj, pkg.Types) {
+				cursor.Replace(&ast.SelectorExpr{X: ast.NewIdent(p.imports.names[obj.Pkg().Path()]), Sel: ast.NewIdent(obj.Name())})
+				return true
 			}
-			funcDecl.Type.Results.List[i].Type = &ast.StarExpr{
-				X: ast.NewIdent(selExpr.Sel.Name),
+			// ドットインポートされたバンドル対象のパッケージのobjectも、宣言されているパッケージのobjectとしてrenameする
+			if obj, ok := p.Packages.packageLevelObjectOf(obj); ok {
+				n.Name = p.Names.Of(obj)
 			}
 		}
-	}
+		return true
+	}, nil)
 }
 
-// package名の部分を削除したCallExprを返します(非破壊). 存在しない名前の関数である場合や想定しない構造の場合はnilを返します.
-// 標準パッケージの呼び出しである場合は書き換えを行いません。
-func removePackageFromCallExpr(callExpr *ast.CallExpr, pkg *packages.Package) *ast.CallExpr {
-	if ident, ok := callExpr.Fun.(*ast.Ident); ok {
-		obj := pkg.TypesInfo.ObjectOf(ident)
-
-		if _, ok := obj.(*types.Func); !ok {
-			return callExpr
-		}
-		if !util.HasPkg(obj) || util.IsStandardPackage(obj.Pkg().Path()) {
-			return callExpr
-		}
-
-		// 置き換え
-		newCallExpr := astcopy.CallExpr(callExpr)
-		newCallExpr.Fun = &ast.BasicLit{
-			Kind:  token.STRING,
-			Value: renameFunc(obj.Pkg(), ident.Name),
+// packageLevelObjectOf はobjがバンドル対象のパッケージレベルのobjectであれば、renameに使うobjectを返します。
+// 埋め込みフィールドのフィールド名は型名から決まるため、埋め込みフィールドの場合は型を返します。
+func (p *Packages) packageLevelObjectOf(obj types.Object) (types.Object, bool) {
+	switch o := obj.(type) {
+	case nil:
+		return nil, false
+	case *types.Func:
+		obj = o.Origin()
+	case *types.Var:
+		if typeName, ok := embeddedFieldTypeName(o); ok {
+			obj = typeName
 		}
-		newCallExpr.Args = callExpr.Args
-		return newCallExpr
-	}
-
-	selExpr, ok := callExpr.Fun.(*ast.SelectorExpr)
-	if !ok {
-		return nil
-	}
-
-	xident, ok := selExpr.X.(*ast.Ident)
-	if !ok {
-		return nil
-	}
-	obj := pkg.TypesInfo.ObjectOf(xident)
-	if !util.HasPkg(obj) {
-		return callExpr
 	}
+	return obj, p.isPackageLevelObject(obj)
+}
 
-	if !util.HasPkg(obj) {
-		return callExpr
-	}
-	pkgName, ok := obj.(*types.PkgName)
-	if !ok {
-		return callExpr
+// embeddedFieldTypeName は埋め込みフィールドの型を返します。埋め込みフィールドでない場合はfalseを返します。
+func embeddedFieldTypeName(v *types.Var) (*types.TypeName, bool) {
+	if !v.Embedded() {
+		return nil, false
 	}
-	if util.IsStandardPackage(pkgName.Imported().Path()) {
-		return callExpr
+	t := v.Type()
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
 	}
-
-	// 置き換え
-	newCallExpr := astcopy.CallExpr(callExpr)
-
-	// type castの場合は書き換えない FIXME: いつかは書き換えることになる
-	if _, ok := pkg.TypesInfo.ObjectOf(selExpr.Sel).(*types.Func); !ok {
-		newCallExpr.Fun = &ast.BasicLit{
-			Kind:  token.STRING,
-			Value: selExpr.Sel.Name,
+	// エイリアスを埋め込んだ場合、フィールド名はエイリアスの名前になる
+	switch t := t.(type) {
+	case *types.Alias:
+		return t.Obj(), true
+	case *types.Named:
+		obj := t.Origin().Obj()
+		// エイリアスが型情報に残らない場合(gotypesalias=0)は、フィールド名から同じパッケージのエイリアスを探す
+		if obj.Name() != v.Name() && obj.Pkg() != nil {
+			if alias, ok := obj.Pkg().Scope().Lookup(v.Name()).(*types.TypeName); ok && alias.IsAlias() {
+				return alias, true
+			}
 		}
-		newCallExpr.Args = callExpr.Args
-		return newCallExpr
-	}
-
-	newCallExpr.Fun = &ast.BasicLit{
-		Kind:  token.STRING,
-		Value: renameFunc(pkgName.Imported(), selExpr.Sel.Name),
+		return obj, true
 	}
-	newCallExpr.Args = callExpr.Args
-	return newCallExpr
+	return nil, false
 }
 
-// package名の部分を削除したCompositeLitを返します(非破壊). 存在しない名前の関数である場合や想定しない構造の場合はnilを返します.
-func removePackageFromSelectorExpr(selector *ast.SelectorExpr, pkg *packages.Package) *ast.Ident {
-	x, ok := selector.X.(*ast.Ident)
-	if !ok {
-		return nil
-	}
-	obj := pkg.TypesInfo.ObjectOf(x)
-	if !util.HasPkg(obj) {
-		return nil
-	}
-	pkgName, ok := obj.(*types.PkgName)
-	if !ok {
-		return nil
-	}
-	if util.IsStandardPackage(pkgName.Imported().Path()) {
-		return nil
+// isPackageLevelObject はバンドル対象のパッケージのスコープで宣言されたobjectであればtrueを返します
+func (p *Packages) isPackageLevelObject(obj types.Object) bool {
+	if !p.isBundledObject(obj) {
+		return false
 	}
-	return ast.NewIdent(renameFunc(obj.Pkg(), selector.Sel.Name))
+	return obj.Parent() == obj.Pkg().Scope()
 }
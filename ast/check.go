@@ -0,0 +1,193 @@
+package ast
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// Severity は検出した構文の深刻度です
+type Severity int
+
+const (
+	// SeverityWarning はバンドルできるものの、元のプログラムと動作が異なる可能性がある構文です
+	SeverityWarning Severity = iota
+	// SeverityError はバンドルできない構文です
+	SeverityError
+)
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// Diagnostic は安全にバンドルできない構文と、その位置です
+type Diagnostic struct {
+	Pos      token.Position
+	Severity Severity
+	Message  string
+}
+
+func (d *Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s", d.Pos, d.Message)
+}
+
+// unsafePointerFuncs はポインタを直接操作するunsafeパッケージのobjectです。Sizeofなどの型のサイズを返す関数は対象外です。
+var unsafePointerFuncs = map[string]bool{"Pointer": true, "Add": true, "Slice": true, "SliceData": true, "String": true, "StringData": true}
+
+// reflectNameMethods は型名やメソッド・フィールドの名前に依存するreflectパッケージのメソッドを、レシーバの型名.メソッド名で表したものです。
+// バンドル後は型や埋め込みフィールドがrenameされ、到達可能でないメソッドは削除されるため、結果が変わる可能性があります。
+// reflect.Value.Stringは値の文字列を返すため対象外です。
+var reflectNameMethods = map[string]bool{
+	"Type.Name": true, "Type.PkgPath": true, "Type.String": true, "Type.FieldByName": true, "Type.FieldByNameFunc": true,
+	"Type.Method": true, "Type.MethodByName": true, "Type.NumMethod": true,
+	"Value.FieldByName": true, "Value.FieldByNameFunc": true, "Value.Method": true, "Value.MethodByName": true, "Value.NumMethod": true,
+}
+
+// CheckUnsupported は到達可能なobjectの宣言から、安全にバンドルできない構文を検出して位置の順に返します。
+// cgo・本体の無い関数・go:linkname・embed.FSなどのstring型と[]byte型以外のgo:embedはバンドルできないためエラー、unsafeのポインタ操作と名前に依存するreflectの呼び出しは警告とします。
+func (p *Packages) CheckUnsupported(objects []types.Object) (diags []*Diagnostic) {
+	seen := map[string]bool{}
+	report := func(pos token.Position, severity Severity, format string, args ...interface{}) {
+		d := &Diagnostic{Pos: pos, Severity: severity, Message: fmt.Sprintf(format, args...)}
+		if key := d.String(); !seen[key] {
+			seen[key] = true
+			diags = append(diags, d)
+		}
+	}
+
+	checkedPkgs := map[string]bool{}
+	for _, object := range objects {
+		decl := p.FindDeclByObject(object)
+		if decl == nil {
+			continue
+		}
+		pkg := p.getPkg(object.Pkg().Path())
+		// cgoが生成したファイルの宣言は、cgoのエラーとしてまとめて報告する
+		if !isGoFile(pkg, pkg.Fset.Position(decl.Pos()).Filename) {
+			continue
+		}
+		if !checkedPkgs[pkg.PkgPath] {
+			checkedPkgs[pkg.PkgPath] = true
+			for _, pos := range cgoImports(pkg) {
+				report(pos, SeverityError, "cgo is not supported: package %s imports \"C\"", pkg.PkgPath)
+			}
+		}
+
+		if _, ok := p.findEmbedPatterns(object); ok {
+			if _, ok := isEmbeddableType(object.Type()); !ok {
+				report(pkg.Fset.Position(object.Pos()), SeverityError, "go:embed is only supported for string and []byte variables: %s has type %s", object.Name(), object.Type())
+			}
+		}
+
+		if pos, ok := findLinkname(pkg, decl, object.Name()); ok {
+			report(pkg.Fset.Position(pos), SeverityError, "go:linkname is not supported: %s", object.Name())
+		} else if funcDecl, ok := decl.(*ast.FuncDecl); ok && funcDecl.Body == nil {
+			report(pkg.Fset.Position(funcDecl.Pos()), SeverityError, "function %s has no body. functions implemented in assembly are not supported", object.Name())
+		}
+
+		ast.Inspect(decl, func(n ast.Node) bool {
+			switch t := n.(type) {
+			case *ast.SelectorExpr:
+				x, ok := t.X.(*ast.Ident)
+				if !ok {
+					return true
+				}
+				if pkgName, ok := pkg.TypesInfo.Uses[x].(*types.PkgName); ok && pkgName.Imported().Path() == "unsafe" && unsafePointerFuncs[t.Sel.Name] {
+					report(pkg.Fset.Position(t.Pos()), SeverityWarning, "unsafe.%s is used. the bundle may behave differently", t.Sel.Name)
+				}
+			case *ast.CallExpr:
+				f, ok := typeutil.Callee(pkg.TypesInfo, t).(*types.Func)
+				if !ok || f.Pkg() == nil || f.Pkg().Path() != "reflect" {
+					return true
+				}
+				recv := f.Type().(*types.Signature).Recv()
+				if recv == nil {
+					return true
+				}
+				if method := typeName(recv.Type()) + "." + f.Name(); reflectNameMethods[method] {
+					report(pkg.Fset.Position(t.Pos()), SeverityWarning, "reflect.%s depends on names or method sets, which are changed by bundling", method)
+				}
+			}
+			return true
+		})
+	}
+
+	sort.SliceStable(diags, func(i, j int) bool {
+		pi, pj := diags[i].Pos, diags[j].Pos
+		if pi.Filename != pj.Filename {
+			return pi.Filename < pj.Filename
+		}
+		if pi.Line != pj.Line {
+			return pi.Line < pj.Line
+		}
+		return pi.Column < pj.Column
+	})
+	return
+}
+
+// cgoImports はパッケージの"C"をimportしている位置を返します。
+// cgoを使うパッケージの構文木はcgoが生成したファイルから作られるため、元のファイルのimportを読み込みます。
+func cgoImports(pkg *packages.Package) (positions []token.Position) {
+	fset := token.NewFileSet()
+	for _, filename := range pkg.GoFiles {
+		file, err := parser.ParseFile(fset, filename, nil, parser.ImportsOnly)
+		if err != nil {
+			continue
+		}
+		for _, spec := range file.Imports {
+			if spec.Path.Value == `"C"` {
+				positions = append(positions, fset.Position(spec.Pos()))
+			}
+		}
+	}
+	return
+}
+
+// isGoFile はfilenameがパッケージのGoのソースファイルであればtrueを返します
+func isGoFile(pkg *packages.Package, filename string) bool {
+	for _, goFile := range pkg.GoFiles {
+		if goFile == filename {
+			return true
+		}
+	}
+	return false
+}
+
+// findLinkname はdeclを含むファイルで、nameに対するgo:linknameディレクティブの位置を返します
+func findLinkname(pkg *packages.Package, decl ast.Decl, name string) (token.Pos, bool) {
+	for _, file := range pkg.Syntax {
+		if decl.Pos() < file.Pos() || file.End() < decl.End() {
+			continue
+		}
+		for _, group := range file.Comments {
+			for _, comment := range group.List {
+				args, ok := strings.CutPrefix(comment.Text, "//go:linkname ")
+				if fields := strings.Fields(args); ok && len(fields) > 0 && fields[0] == name {
+					return comment.Pos(), true
+				}
+			}
+		}
+	}
+	return token.NoPos, false
+}
+
+// typeName は型の名前を返します。名前の無い型の場合は型の文字列表現を返します。
+func typeName(t types.Type) string {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if named, ok := t.(*types.Named); ok {
+		return named.Obj().Name()
+	}
+	return t.String()
+}
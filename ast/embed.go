@@ -0,0 +1,142 @@
+package ast
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/afero"
+)
+
+// DefaultEmbedSizeWarningThreshold は埋め込むファイルのサイズについて警告する閾値(バイト)のデフォルト値です。
+// ジャッジによっては提出できるソースコードのサイズに上限があるため、大きなファイルを埋め込む場合は警告します。
+const DefaultEmbedSizeWarningThreshold = 64 * 1024
+
+// InlineEmbeddedFiles は//go:embedが付与された変数を、ファイルの内容で初期化される変数に置き換えます。
+// ファイルはfsから読み込みます。warnSizeより大きいファイルを埋め込む場合は警告を出力します(0以下の場合は警告しません)。
+// string型と[]byte型の変数のみ対応しており、embed.FS型の変数が含まれる場合はエラーを返します。
+func (p *Program) InlineEmbeddedFiles(fs afero.Fs, warnSize int) error {
+	for i, genDecl := range p.Vars {
+		object := p.VarObjects[i]
+		patterns, ok := p.Packages.findEmbedPatterns(object)
+		if !ok {
+			continue
+		}
+
+		isBytes, ok := isEmbeddableType(object.Type())
+		if !ok {
+			return fmt.Errorf("go:embed inlining does not support %s: %s.%s", object.Type(), object.Pkg().Name(), object.Name())
+		}
+
+		pkg := p.Packages.getPkg(object.Pkg().Path())
+		dir := filepath.Dir(pkg.Fset.Position(object.Pos()).Filename)
+		var files []string
+		for _, pattern := range patterns {
+			matches, err := afero.Glob(fs, filepath.Join(dir, filepath.FromSlash(pattern)))
+			if err != nil {
+				return fmt.Errorf("failed to resolve go:embed pattern %q of %s.%s: %w", pattern, object.Pkg().Name(), object.Name(), err)
+			}
+			files = append(files, matches...)
+		}
+		if len(files) != 1 {
+			return fmt.Errorf("go:embed patterns %q of %s.%s must match exactly one file, but matched %d files", patterns, object.Pkg().Name(), object.Name(), len(files))
+		}
+
+		content, err := afero.ReadFile(fs, files[0])
+		if err != nil {
+			return fmt.Errorf("failed to read embedded file of %s.%s: %w", object.Pkg().Name(), object.Name(), err)
+		}
+		if warnSize > 0 && len(content) > warnSize {
+			log.Printf("warn: embedded file %s is %d bytes, which is larger than %d bytes", files[0], len(content), warnSize)
+		}
+
+		valueSpec := genDecl.Specs[0].(*ast.ValueSpec)
+		valueSpec.Type = nil
+		valueSpec.Values = []ast.Expr{newEmbedLiteral(content, isBytes)}
+	}
+	return nil
+}
+
+// isEmbeddableType はtがファイルの内容で初期化できるstring型か[]byte型であればtrueを返します。isBytesは[]byte型の場合にtrueです
+func isEmbeddableType(t types.Type) (isBytes bool, ok bool) {
+	switch t := types.Unalias(t).(type) {
+	case *types.Basic:
+		return false, t.Kind() == types.String
+	case *types.Slice:
+		elem, ok := types.Unalias(t.Elem()).(*types.Basic)
+		return true, ok && elem.Kind() == types.Byte
+	}
+	return false, false
+}
+
+// findEmbedPatterns はobjectの宣言に付与された//go:embedのパターンを返します
+func (p *Packages) findEmbedPatterns(object types.Object) ([]string, bool) {
+	genDecl, ok := p.FindDeclByObject(object).(*ast.GenDecl)
+	if !ok || genDecl.Tok != token.VAR {
+		return nil, false
+	}
+	spec, ok := p.findSpecByObject(object)
+	if !ok {
+		return nil, false
+	}
+	// 括弧を使わない宣言の場合、コメントはGenDeclに付与される
+	docs := []*ast.CommentGroup{spec.(*ast.ValueSpec).Doc}
+	if !genDecl.Lparen.IsValid() {
+		docs = append(docs, genDecl.Doc)
+	}
+
+	var patterns []string
+	for _, doc := range docs {
+		if doc == nil {
+			continue
+		}
+		for _, comment := range doc.List {
+			args, ok := strings.CutPrefix(comment.Text, "//go:embed ")
+			if !ok {
+				continue
+			}
+			patterns = append(patterns, parseEmbedPatterns(args)...)
+		}
+	}
+	return patterns, len(patterns) > 0
+}
+
+// parseEmbedPatterns は空白区切りのパターンを分割します。パターンはダブルクォートやバッククォートで囲むこともできます
+func parseEmbedPatterns(args string) (patterns []string) {
+	for args = strings.TrimSpace(args); args != ""; args = strings.TrimSpace(args) {
+		if quoted, err := strconv.QuotedPrefix(args); err == nil {
+			pattern, _ := strconv.Unquote(quoted)
+			patterns = append(patterns, pattern)
+			args = args[len(quoted):]
+			continue
+		}
+		i := strings.IndexAny(args, " \t")
+		if i < 0 {
+			i = len(args)
+		}
+		patterns = append(patterns, args[:i])
+		args = args[i:]
+	}
+	return
+}
+
+// newEmbedLiteral はファイルの内容を表すリテラルを返します。
+// 可能であれば読みやすさのためにraw文字列リテラルを使います。
+func newEmbedLiteral(content []byte, isBytes bool) ast.Expr {
+	s := string(content)
+	value := strconv.Quote(s)
+	if utf8.ValidString(s) && !strings.ContainsAny(s, "`\r\x00\ufeff") {
+		value = "`" + s + "`"
+	}
+	var lit ast.Expr = &ast.BasicLit{Kind: token.STRING, Value: value}
+	if isBytes {
+		lit = &ast.CallExpr{Fun: &ast.ArrayType{Elt: ast.NewIdent("byte")}, Args: []ast.Expr{lit}}
+	}
+	return lit
+}
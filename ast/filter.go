@@ -0,0 +1,55 @@
+package ast
+
+import (
+	"github.com/mpppk/gollup/util"
+)
+
+// PackageFilter はimportされているパッケージのうち、バンドルするパッケージをimport pathのパターンで指定します。
+// Includeが空の場合は標準パッケージ以外の全てのパッケージが対象です。Excludeにマッチするパッケージはimportのまま残されます。
+// KeepImportsにはジャッジ環境に用意されているパッケージなど、標準パッケージと同様に扱うパッケージを指定します。
+// KeepImportsにマッチするパッケージは、コマンドライン引数で指定された場合もバンドルされません。
+type PackageFilter struct {
+	Include     []string
+	Exclude     []string
+	KeepImports []string
+}
+
+// IsKept は指定したimport pathのパッケージを標準パッケージと同様に扱う場合にtrueを返します
+func (f *PackageFilter) IsKept(path string) bool {
+	if util.IsStandardPackage(path) {
+		return true
+	}
+	if f == nil {
+		return false
+	}
+	for _, pattern := range f.KeepImports {
+		if util.MatchPackagePattern(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match は指定したimport pathのパッケージをバンドルする場合にtrueを返します
+func (f *PackageFilter) Match(path string) bool {
+	if f.IsKept(path) {
+		return false
+	}
+	if f == nil {
+		return true
+	}
+	for _, pattern := range f.Exclude {
+		if util.MatchPackagePattern(pattern, path) {
+			return false
+		}
+	}
+	if len(f.Include) == 0 {
+		return true
+	}
+	for _, pattern := range f.Include {
+		if util.MatchPackagePattern(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
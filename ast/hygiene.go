@@ -0,0 +1,153 @@
+package ast
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"log"
+	"strconv"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// renameShadowingLocals はrenameされる参照がローカルな識別子に隠されないように、衝突するローカルな識別子をrenameします。破壊的メソッドです。
+// 例えばlib.F1はlib_F1にrenameされるため、参照しているスコープにlib_F1という名前のローカル変数があると、そのローカル変数を参照してしまいます。
+// importで別の名前を付けたパッケージや、ドットインポートされたパッケージの参照についても同様です。
+func (p *Program) renameShadowingLocals() {
+	renamed := map[types.Object]bool{}
+	rename := func(pkg *packages.Package, ident *ast.Ident, name string) {
+		local, ok := lookupLocal(pkg, ident.Pos(), name)
+		if !ok || renamed[local] {
+			return
+		}
+		newName := p.renameLocal(pkg, local)
+		renamed[local] = true
+		log.Printf("debug: renamed %s to %s at %s because it shadows %s", local.Name(), newName, pkg.Fset.Position(local.Pos()), name)
+	}
+	nodes, pkgs := p.declNodes()
+	for i, node := range nodes {
+		pkg := p.Packages.getPkg(pkgs[i].Path())
+		// バンドルされないパッケージは、importで付けた名前で参照される
+		p.inspectExternalReferences(node, pkgs[i], func(ident *ast.Ident, obj types.Object) {
+			path := obj.Pkg().Path()
+			if pkgName, ok := obj.(*types.PkgName); ok {
+				path = pkgName.Imported().Path()
+			}
+			rename(pkg, ident, p.imports.names[path])
+		})
+		ast.Inspect(node, func(n ast.Node) bool {
+			ident, ok := n.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			// フィールドはスコープから解決されないため、埋め込みフィールドの名前が変わっても隠されることはない
+			if v, ok := pkg.TypesInfo.Uses[ident].(*types.Var); ok && v.IsField() {
+				return true
+			}
+			obj, ok := p.Packages.packageLevelObjectOf(pkg.TypesInfo.Uses[ident])
+			if !ok {
+				return true
+			}
+			rename(pkg, ident, p.Names.Of(obj))
+			return true
+		})
+	}
+}
+
+// lookupLocal はposから見えるnameという名前のローカルな識別子を返します
+func lookupLocal(pkg *packages.Package, pos token.Pos, name string) (types.Object, bool) {
+	scope := pkg.Types.Scope().Innermost(pos)
+	if scope == nil {
+		return nil, false
+	}
+	_, obj := scope.LookupParent(name, pos)
+	if obj == nil || obj.Parent() == pkg.Types.Scope() || obj.Parent() == types.Universe {
+		return nil, false
+	}
+	// ファイルスコープにはimportしたパッケージ名のみが存在する
+	if _, ok := obj.(*types.PkgName); ok {
+		return nil, false
+	}
+	return obj, true
+}
+
+// renameLocal はローカルな識別子を、宣言されたスコープと参照している全てのスコープで他の名前と衝突しない名前にrenameし、その名前を返します
+func (p *Program) renameLocal(pkg *packages.Package, local types.Object) string {
+	objects, idents := typeSwitchObjects(pkg, local)
+	if objects == nil {
+		objects = []types.Object{local}
+	}
+	isTarget := func(obj types.Object) bool {
+		for _, o := range objects {
+			if o == obj {
+				return true
+			}
+		}
+		return false
+	}
+	for ident, obj := range pkg.TypesInfo.Defs {
+		if obj != nil && isTarget(obj) {
+			idents = append(idents, ident)
+		}
+	}
+	for ident, obj := range pkg.TypesInfo.Uses {
+		if isTarget(obj) {
+			idents = append(idents, ident)
+		}
+	}
+
+	isFree := func(name string) bool {
+		if p.Names.taken[name] {
+			return false
+		}
+		for _, obj := range objects {
+			if _, o := obj.Parent().LookupParent(name, token.NoPos); o != nil {
+				return false
+			}
+		}
+		for _, ident := range idents {
+			if _, ok := lookupLocal(pkg, ident.Pos(), name); ok {
+				return false
+			}
+		}
+		return true
+	}
+	name := local.Name()
+	for i := 1; !isFree(name); i++ {
+		name = local.Name() + "_" + strconv.Itoa(i)
+	}
+	p.Names.taken[name] = true
+	for _, ident := range idents {
+		ident.Name = name
+	}
+	return name
+}
+
+// typeSwitchObjects はlocalが型switchの変数であれば、各caseで暗黙的に宣言される変数と、switch文で宣言している識別子を返します
+func typeSwitchObjects(pkg *packages.Package, local types.Object) (objects []types.Object, idents []*ast.Ident) {
+	for _, file := range pkg.Syntax {
+		ast.Inspect(file, func(n ast.Node) bool {
+			typeSwitch, ok := n.(*ast.TypeSwitchStmt)
+			if !ok || objects != nil {
+				return objects == nil
+			}
+			assign, ok := typeSwitch.Assign.(*ast.AssignStmt)
+			if !ok {
+				return true
+			}
+			var implicits []types.Object
+			found := false
+			for _, stmt := range typeSwitch.Body.List {
+				if obj := pkg.TypesInfo.Implicits[stmt]; obj != nil {
+					implicits = append(implicits, obj)
+					found = found || obj == local
+				}
+			}
+			if found {
+				objects, idents = implicits, []*ast.Ident{assign.Lhs[0].(*ast.Ident)}
+			}
+			return true
+		})
+	}
+	return
+}
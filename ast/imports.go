@@ -0,0 +1,182 @@
+package ast
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+)
+
+// importSet はバンドル後のファイルでimportするパッケージと、それぞれのパッケージを参照する名前です。
+// バンドル対象のパッケージはimportしません。
+type importSet struct {
+	// names はインポートパスからパッケージを参照する名前への対応です
+	names map[string]string
+	pkgs  map[string]*types.Package
+	// paths は名前を付けてimportするパッケージ、blanks は副作用のためだけにimportするパッケージのインポートパスです
+	paths  []string
+	blanks []string
+}
+
+// newImportSet は出力される宣言から参照されている、バンドルされないパッケージのimportを作ります。
+// 同じパッケージが異なる名前でimportされている場合は一つの名前にまとめ、異なるパッケージが同じ名前でimportされている場合は別の名前を付けます。
+// ドットインポートされたパッケージも名前を付けてimportし、参照はその名前で修飾されます。
+func (p *Program) newImportSet() *importSet {
+	imported := map[string]*types.Package{}
+	set := &importSet{names: map[string]string{}, pkgs: imported}
+	mainAliases, aliases := map[string][]string{}, map[string][]string{}
+	addPath := func(pkg *types.Package) {
+		if _, ok := imported[pkg.Path()]; !ok {
+			imported[pkg.Path()] = pkg
+			set.paths = append(set.paths, pkg.Path())
+		}
+	}
+	nodes, pkgs := p.declNodes()
+	for i, node := range nodes {
+		p.inspectExternalReferences(node, pkgs[i], func(ident *ast.Ident, obj types.Object) {
+			pkg, alias := obj.Pkg(), ""
+			if pkgName, ok := obj.(*types.PkgName); ok {
+				pkg, alias = pkgName.Imported(), pkgName.Name()
+			}
+			addPath(pkg)
+			if alias == "" {
+				return
+			}
+			if pkgs[i].Name() == "main" {
+				mainAliases[pkg.Path()] = append(mainAliases[pkg.Path()], alias)
+			} else {
+				aliases[pkg.Path()] = append(aliases[pkg.Path()], alias)
+			}
+		})
+	}
+	// init関数の中で初期化する変数は型を明示して宣言するため、型が参照しているパッケージもimportする
+	for _, varType := range p.deferredVarTypes() {
+		for _, pkg := range p.Packages.externalTypePackages(varType) {
+			addPath(pkg)
+		}
+	}
+
+	// mainパッケージのスコープの名前とは衝突しないように名前を付ける
+	taken := map[string]bool{}
+	if mainPkg := p.mainPackage(); mainPkg != nil {
+		for _, name := range mainPkg.Scope().Names() {
+			taken[name] = true
+		}
+	}
+	// mainパッケージで付けられた名前、パッケージ名、ライブラリで付けられた名前の順に優先する
+	sort.SliceStable(set.paths, func(i, j int) bool {
+		return len(mainAliases[set.paths[i]]) > 0 && len(mainAliases[set.paths[j]]) == 0
+	})
+	for _, path := range set.paths {
+		pkg := imported[path]
+		candidates := append(append(append(mainAliases[path], pkg.Name()), aliases[path]...), pathPrefixes(pkg)...)
+		name := ""
+		for _, candidate := range candidates {
+			if !taken[candidate] {
+				name = candidate
+				break
+			}
+		}
+		for i := 2; name == ""; i++ {
+			if candidate := pkg.Name() + strconv.Itoa(i); !taken[candidate] {
+				name = candidate
+			}
+		}
+		taken[name] = true
+		set.names[path] = name
+	}
+
+	// 副作用のためのimportは、全てのバンドル対象のパッケージから集める
+	for _, pkg := range p.InitOrder {
+		for _, file := range pkg.Syntax {
+			for _, spec := range file.Imports {
+				path, err := strconv.Unquote(spec.Path.Value)
+				if err != nil || spec.Name == nil || spec.Name.Name != "_" || path == "C" {
+					continue
+				}
+				if p.Packages.isBundled(path) || set.contains(path) {
+					continue
+				}
+				set.blanks = append(set.blanks, path)
+			}
+		}
+	}
+	sort.Strings(set.blanks)
+	return set
+}
+
+func (s *importSet) contains(path string) bool {
+	if _, ok := s.names[path]; ok {
+		return true
+	}
+	for _, blank := range s.blanks {
+		if blank == path {
+			return true
+		}
+	}
+	return false
+}
+
+// decl はimport宣言を返します。importするパッケージが無い場合はnilを返します。
+func (s *importSet) decl() *ast.GenDecl {
+	specs := []ast.Spec{}
+	for _, path := range s.paths {
+		spec := &ast.ImportSpec{Path: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(path)}}
+		if name := s.names[path]; name != s.pkgs[path].Name() {
+			spec.Name = ast.NewIdent(name)
+		}
+		specs = append(specs, spec)
+	}
+	for _, path := range s.blanks {
+		specs = append(specs, &ast.ImportSpec{Name: ast.NewIdent("_"), Path: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(path)}})
+	}
+	if len(specs) == 0 {
+		return nil
+	}
+	return &ast.GenDecl{Tok: token.IMPORT, Lparen: 1, Specs: specs}
+}
+
+// inspectExternalReferences はnode中でバンドルされないパッケージを参照している識別子に対してfを呼び出します。
+// パッケージ名で修飾された参照ではパッケージ名の識別子とPkgNameを、ドットインポートされたobjectの参照ではその識別子とobjectを渡します。
+func (p *Program) inspectExternalReferences(node ast.Node, pkg *types.Package, f func(ident *ast.Ident, obj types.Object)) {
+	info := p.Packages.getPkg(pkg.Path()).TypesInfo
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch t := n.(type) {
+		case *ast.SelectorExpr:
+			x, ok := t.X.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			if pkgName, ok := info.Uses[x].(*types.PkgName); ok {
+				if !p.Packages.isBundled(pkgName.Imported().Path()) {
+					f(x, pkgName)
+				}
+				return false
+			}
+		case *ast.Ident:
+			if obj := info.Uses[t]; p.Packages.isDotImportedObject(obj, pkg) {
+				f(t, obj)
+			}
+		}
+		return true
+	})
+}
+
+// isDotImportedObject はobjがpkgにドットインポートされた、バンドルされないパッケージのパッケージレベルのobjectであればtrueを返します
+func (p *Packages) isDotImportedObject(obj types.Object, pkg *types.Package) bool {
+	if obj == nil || obj.Pkg() == nil || obj.Pkg() == pkg || p.isBundledObject(obj) {
+		return false
+	}
+	return obj.Parent() == obj.Pkg().Scope()
+}
+
+// mainPackage はmainパッケージを返します
+func (p *Program) mainPackage() *types.Package {
+	for _, pkg := range p.InitOrder {
+		if pkg.Name == "main" {
+			return pkg.Types
+		}
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package ast
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// InitOrder はmainPkgから参照されている、バンドル対象のパッケージを初期化される順に返します。
+// Goの仕様と同様に、依存しているパッケージが全て初期化済みのパッケージのうち、import pathが最も小さいものから順に初期化されます。
+func (p *Packages) InitOrder(mainPkg *packages.Package) []*packages.Package {
+	var pkgs []*packages.Package
+	packages.Visit([]*packages.Package{mainPkg}, nil, func(pkg *packages.Package) {
+		if p.isBundled(pkg.PkgPath) {
+			pkgs = append(pkgs, pkg)
+		}
+	})
+	sort.Slice(pkgs, func(i, j int) bool {
+		return pkgs[i].PkgPath < pkgs[j].PkgPath
+	})
+
+	var order []*packages.Package
+	initialized := map[string]bool{}
+	for len(order) < len(pkgs) {
+		for _, pkg := range pkgs {
+			if initialized[pkg.PkgPath] || !p.importsInitialized(pkg, initialized) {
+				continue
+			}
+			initialized[pkg.PkgPath] = true
+			order = append(order, pkg)
+			break
+		}
+	}
+	return order
+}
+
+// importsInitialized はpkgがimportしているバンドル対象のパッケージが全て初期化済みであればtrueを返します
+func (p *Packages) importsInitialized(pkg *packages.Package, initialized map[string]bool) bool {
+	for path := range pkg.Imports {
+		if p.isBundled(path) && !initialized[path] {
+			return false
+		}
+	}
+	return true
+}
+
+// InitFuncs はパッケージで宣言されているinit関数を、呼び出される順に返します
+func InitFuncs(pkgs []*packages.Package) (funcs []*types.Func) {
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				funcDecl, ok := decl.(*ast.FuncDecl)
+				if !ok || funcDecl.Recv != nil || funcDecl.Name.Name != "init" {
+					continue
+				}
+				if f, ok := pkg.TypesInfo.Defs[funcDecl.Name].(*types.Func); ok {
+					funcs = append(funcs, f)
+				}
+			}
+		}
+	}
+	return
+}
+
+// renameInitFuncs はinit関数を一意な名前にrenameし、それらを初期化の順に呼び出すinit関数を返します。
+// deferVarInitializersで移された変数の代入は、そのパッケージのinit関数の呼び出しの直前に置かれます。
+// init関数が存在しない場合はnilを返します。
+func (p *Program) renameInitFuncs() *ast.FuncDecl {
+	if len(p.InitFuncs) == 0 {
+		return nil
+	}
+	body := &ast.BlockStmt{}
+	for _, pkg := range p.InitOrder {
+		body.List = append(body.List, p.varInits[pkg.PkgPath]...)
+		for _, f := range p.InitFuncs {
+			if f.Pkg().Path() != pkg.PkgPath {
+				continue
+			}
+			name := p.Names.Of(f)
+			for j, funcDecl := range p.Funcs {
+				if isSameObject(p.FuncObjects[j], f) {
+					funcDecl.Name = ast.NewIdent(name)
+				}
+			}
+			body.List = append(body.List, &ast.ExprStmt{X: &ast.CallExpr{Fun: ast.NewIdent(name)}})
+		}
+	}
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("init"),
+		Type: &ast.FuncType{Params: &ast.FieldList{}},
+		Body: body,
+	}
+}
+
+// deferredVarPackages は変数の初期化を生成するinit関数の中で行うパッケージを返します。
+// 元のプログラムでは、init関数を持つパッケージより後に初期化されるパッケージの変数はそのinit関数の実行後に初期化されますが、
+// バンドル後のファイルでは全ての変数がinit関数より先に初期化されるため、これらのパッケージの変数はinit関数の中で代入します。
+func (p *Program) deferredVarPackages() map[string]bool {
+	deferred := map[string]bool{}
+	hasInit := false
+	for _, pkg := range p.InitOrder {
+		if hasInit {
+			deferred[pkg.PkgPath] = true
+		}
+		for _, f := range p.InitFuncs {
+			hasInit = hasInit || f.Pkg().Path() == pkg.PkgPath
+		}
+	}
+	return deferred
+}
+
+// deferredVarTypes はinit関数の中で初期化する変数のうち、宣言で型が省略されている変数の型を返します
+func (p *Program) deferredVarTypes() (varTypes []types.Type) {
+	deferred := p.deferredVarPackages()
+	for i, genDecl := range p.Vars {
+		spec := genDecl.Specs[0].(*ast.ValueSpec)
+		pkg := p.VarObjects[i].Pkg()
+		if !deferred[pkg.Path()] || spec.Type != nil || len(spec.Values) == 0 {
+			continue
+		}
+		for _, name := range spec.Names {
+			if name.Name != "_" {
+				varTypes = append(varTypes, pkg.Scope().Lookup(name.Name).Type())
+			}
+		}
+	}
+	return
+}
+
+// deferVarInitializers はdeferredVarPackagesのパッケージの変数を初期化式の無い宣言に置き換え、初期化式を代入文としてvarInitsに移します。
+// 代入文はパッケージ内の変数の初期化順(TypesInfo.InitOrder)に並びます。型が省略されている変数は、型を明示して宣言します。
+func (p *Program) deferVarInitializers() error {
+	deferred := p.deferredVarPackages()
+	indices := map[types.Object]int{}
+	for i, genDecl := range p.Vars {
+		for _, name := range genDecl.Specs[0].(*ast.ValueSpec).Names {
+			if name.Name != "_" {
+				indices[p.VarObjects[i].Pkg().Scope().Lookup(name.Name)] = i
+			}
+		}
+	}
+
+	p.varInits = map[string][]ast.Stmt{}
+	replaced := map[int][]*ast.GenDecl{}
+	replacedObjects := map[int][]types.Object{}
+	for _, pkg := range p.InitOrder {
+		if !deferred[pkg.PkgPath] {
+			continue
+		}
+		for _, initializer := range pkg.TypesInfo.InitOrder {
+			index := -1
+			var lhs []ast.Expr
+			for _, v := range initializer.Lhs {
+				i, ok := indices[v]
+				if !ok {
+					lhs = append(lhs, ast.NewIdent("_"))
+					continue
+				}
+				index = i
+				lhs = append(lhs, ast.NewIdent(p.Names.Of(v)))
+			}
+			if index < 0 {
+				continue
+			}
+			spec := p.Vars[index].Specs[0].(*ast.ValueSpec)
+			p.varInits[pkg.PkgPath] = append(p.varInits[pkg.PkgPath], &ast.AssignStmt{Lhs: lhs, Tok: token.ASSIGN, Rhs: spec.Values})
+			for _, v := range initializer.Lhs {
+				if _, ok := indices[v]; !ok {
+					continue
+				}
+				varType := spec.Type
+				if varType == nil {
+					var err error
+					if varType, err = p.typeExpr(v.Type()); err != nil {
+						return fmt.Errorf("failed to move the initializer of %s.%s into init: %w", v.Pkg().Path(), v.Name(), err)
+					}
+				}
+				newSpec := &ast.ValueSpec{Names: []*ast.Ident{ast.NewIdent(v.Name())}, Type: varType}
+				replaced[index] = append(replaced[index], &ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{newSpec}})
+				replacedObjects[index] = append(replacedObjects[index], v)
+			}
+		}
+	}
+
+	var vars []*ast.GenDecl
+	var varObjects []types.Object
+	for i, genDecl := range p.Vars {
+		if decls, ok := replaced[i]; ok {
+			vars, varObjects = append(vars, decls...), append(varObjects, replacedObjects[i]...)
+			continue
+		}
+		vars, varObjects = append(vars, genDecl), append(varObjects, p.VarObjects[i])
+	}
+	p.Vars, p.VarObjects = vars, varObjects
+	return nil
+}
+
+// sortVarsByInitOrder は変数の宣言を、パッケージの初期化順・ファイル順・宣言順に並び替えます。
+// 一つのパッケージ内では、変数は依存関係を満たす範囲で宣言順に初期化されるため、元のプログラムと同じ順に初期化されます。
+func (p *Program) sortVarsByInitOrder() {
+	type key struct {
+		pkg, file int
+		pos       token.Pos
+	}
+	keyOf := func(object types.Object) key {
+		for i, pkg := range p.InitOrder {
+			if pkg.PkgPath != object.Pkg().Path() {
+				continue
+			}
+			for j, file := range pkg.Syntax {
+				if file.Pos() <= object.Pos() && object.Pos() < file.End() {
+					return key{pkg: i, file: j, pos: object.Pos()}
+				}
+			}
+			return key{pkg: i, file: len(pkg.Syntax), pos: object.Pos()}
+		}
+		return key{pkg: len(p.InitOrder), pos: object.Pos()}
+	}
+	less := func(k1, k2 key) bool {
+		if k1.pkg != k2.pkg {
+			return k1.pkg < k2.pkg
+		}
+		if k1.file != k2.file {
+			return k1.file < k2.file
+		}
+		return k1.pos < k2.pos
+	}
+
+	indices := make([]int, len(p.Vars))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return less(keyOf(p.VarObjects[indices[i]]), keyOf(p.VarObjects[indices[j]]))
+	})
+	vars := make([]*ast.GenDecl, len(p.Vars))
+	varObjects := make([]types.Object, len(p.VarObjects))
+	for i, index := range indices {
+		vars[i] = p.Vars[index]
+		varObjects[i] = p.VarObjects[index]
+	}
+	p.Vars, p.VarObjects = vars, varObjects
+}
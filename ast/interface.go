@@ -0,0 +1,286 @@
+package ast
+
+import (
+	"errors"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"log"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// DefaultWellKnownInterfaces は標準パッケージの内部で暗黙的にメソッドが呼び出されるインターフェースのデフォルト値です。
+// 値がインターフェース型に変換された場合、その値の型がこれらのインターフェースを満たすために必要なメソッドは残されます。
+var DefaultWellKnownInterfaces = []string{"error", "fmt.Stringer", "fmt.GoStringer", "fmt.Formatter"}
+
+// LookupInterfaces は"fmt.Stringer"や"container/heap.Interface"のような名前からインターフェースを返します。
+// 読み込まれたパッケージから参照されていないパッケージのインターフェースは無視されます。
+func (p *Packages) LookupInterfaces(names []string) ([]*types.Named, error) {
+	var ifaces []*types.Named
+	for _, name := range names {
+		obj, ok := p.lookupQualifiedName(name)
+		if !ok {
+			log.Println("debug: interface is not found in loaded packages:", name)
+			continue
+		}
+		named, ok := types.Unalias(obj.Type()).(*types.Named)
+		if !ok || !types.IsInterface(named) {
+			return nil, errors.New("specified name is not interface: " + name)
+		}
+		ifaces = append(ifaces, named)
+	}
+	return ifaces, nil
+}
+
+func (p *Packages) lookupQualifiedName(name string) (types.Object, bool) {
+	i := strings.LastIndex(name, ".")
+	if i < 0 {
+		obj := types.Universe.Lookup(name)
+		return obj, obj != nil
+	}
+	pkgPath, objName := name[:i], name[i+1:]
+
+	var obj types.Object
+	var pkgs []*packages.Package
+	for _, pkg := range p.Packages {
+		pkgs = append(pkgs, pkg)
+	}
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if obj == nil && pkg.PkgPath == pkgPath && pkg.Types != nil {
+			obj = pkg.Types.Scope().Lookup(objName)
+		}
+	})
+	return obj, obj != nil
+}
+
+// extractStdInterfaceMethodsFromFuncDecl は指定したfuncDecl内で、バンドル対象の型の値がインターフェース型に変換される箇所を探し、
+// 変換先のインターフェースのメソッドと、wellKnownInterfacesを満たすために必要なメソッドを返す。
+// 引数・型変換・代入・変数の宣言・return・複合リテラルの要素・チャネルへの送信で、インターフェース型の値になる箇所を対象とする。
+// インターフェースに変換された値は標準パッケージの内部でメソッドが呼び出される可能性があるため、値の型が持つメソッドを残す
+func (p *Packages) extractStdInterfaceMethodsFromFuncDecl(info *types.Info, targetFuncDecl *ast.FuncDecl, wellKnownInterfaces []*types.Named) (methods []*types.Func) {
+	convert := func(expr ast.Expr, target types.Type) {
+		if target == nil || !types.IsInterface(target) {
+			return
+		}
+		t := info.TypeOf(expr)
+		if t == nil || types.IsInterface(t) {
+			return
+		}
+		methods = append(methods, p.requiredMethods(t, target, wellKnownInterfaces)...)
+	}
+
+	var stack []ast.Node
+	ast.Inspect(targetFuncDecl, func(node ast.Node) bool {
+		if node == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		stack = append(stack, node)
+
+		switch n := node.(type) {
+		case *ast.CallExpr:
+			tv, ok := info.Types[n.Fun]
+			if !ok {
+				return true
+			}
+			// 型変換
+			if tv.IsType() {
+				if len(n.Args) == 1 {
+					convert(n.Args[0], tv.Type)
+				}
+				return true
+			}
+			sig, ok := tv.Type.Underlying().(*types.Signature)
+			if !ok {
+				return true
+			}
+			for i, arg := range n.Args {
+				convert(arg, paramType(sig, i, n.Ellipsis.IsValid()))
+			}
+		case *ast.AssignStmt:
+			if n.Tok != token.ASSIGN || len(n.Lhs) != len(n.Rhs) {
+				return true
+			}
+			for i := range n.Lhs {
+				convert(n.Rhs[i], info.TypeOf(n.Lhs[i]))
+			}
+		case *ast.ValueSpec:
+			if n.Type == nil || len(n.Names) != len(n.Values) {
+				return true
+			}
+			for _, value := range n.Values {
+				convert(value, info.TypeOf(n.Type))
+			}
+		case *ast.ReturnStmt:
+			results := enclosingResults(info, stack)
+			if results == nil || results.Len() != len(n.Results) {
+				return true
+			}
+			for i, result := range n.Results {
+				convert(result, results.At(i).Type())
+			}
+		case *ast.CompositeLit:
+			t := info.TypeOf(n)
+			if t == nil {
+				return true
+			}
+			for i, elt := range n.Elts {
+				key, value := compositeLitElt(elt)
+				switch u := t.Underlying().(type) {
+				case *types.Slice:
+					convert(value, u.Elem())
+				case *types.Array:
+					convert(value, u.Elem())
+				case *types.Map:
+					convert(key, u.Key())
+					convert(value, u.Elem())
+				case *types.Struct:
+					if ident, ok := key.(*ast.Ident); ok {
+						if field, ok := info.Uses[ident].(*types.Var); ok {
+							convert(value, field.Type())
+						}
+					} else if key == nil && i < u.NumFields() {
+						convert(value, u.Field(i).Type())
+					}
+				}
+			}
+		case *ast.SendStmt:
+			if ch, ok := info.TypeOf(n.Chan).Underlying().(*types.Chan); ok {
+				convert(n.Value, ch.Elem())
+			}
+		}
+		return true
+	})
+	return
+}
+
+// paramType はi番目の引数が渡される引数の型を返します。可変長引数の場合は要素の型を返します
+func paramType(sig *types.Signature, i int, hasEllipsis bool) types.Type {
+	params := sig.Params()
+	if params.Len() == 0 {
+		return nil
+	}
+	if !sig.Variadic() || i < params.Len()-1 {
+		if i >= params.Len() {
+			return nil
+		}
+		return params.At(i).Type()
+	}
+	last := params.At(params.Len() - 1).Type()
+	if hasEllipsis {
+		return last
+	}
+	if slice, ok := last.Underlying().(*types.Slice); ok {
+		return slice.Elem()
+	}
+	return nil
+}
+
+// enclosingResults はstackの最も内側の関数の戻り値を返します
+func enclosingResults(info *types.Info, stack []ast.Node) *types.Tuple {
+	for i := len(stack) - 1; i >= 0; i-- {
+		var t types.Type
+		switch n := stack[i].(type) {
+		case *ast.FuncDecl:
+			if obj := info.Defs[n.Name]; obj != nil {
+				t = obj.Type()
+			}
+		case *ast.FuncLit:
+			t = info.TypeOf(n)
+		default:
+			continue
+		}
+		if sig, ok := t.(*types.Signature); ok {
+			return sig.Results()
+		}
+		return nil
+	}
+	return nil
+}
+
+// compositeLitElt は複合リテラルの要素のキーと値を返します。キーが無い場合はnilを返します
+func compositeLitElt(elt ast.Expr) (key, value ast.Expr) {
+	if kv, ok := elt.(*ast.KeyValueExpr); ok {
+		return kv.Key, kv.Value
+	}
+	return nil, elt
+}
+
+// requiredMethods は型tの値がインターフェース型targetに変換される場合に必要なメソッドを返します。
+// targetのメソッドに加えて、tとtから辿れるバンドル対象の型のメソッドのうち、wellKnownInterfacesを満たすものを返します。
+func (p *Packages) requiredMethods(t, target types.Type, wellKnownInterfaces []*types.Named) (methods []*types.Func) {
+	appendMethod := func(t types.Type, m *types.Func) {
+		obj, _, _ := types.LookupFieldOrMethod(t, true, m.Pkg(), m.Name())
+		if f, ok := obj.(*types.Func); ok && p.isBundledObject(f) {
+			methods = append(methods, f.Origin())
+		}
+	}
+
+	for _, m := range interfaceMethods(target, nil) {
+		appendMethod(t, m)
+	}
+	for _, named := range p.bundledNamedTypes(t, map[types.Type]bool{}) {
+		for _, wellKnownInterface := range wellKnownInterfaces {
+			iface := wellKnownInterface.Underlying().(*types.Interface)
+			if !types.Implements(named, iface) && !types.Implements(types.NewPointer(named), iface) {
+				continue
+			}
+			for _, m := range interfaceMethods(iface, nil) {
+				appendMethod(named, m)
+			}
+		}
+	}
+	return
+}
+
+// interfaceMethods はインターフェースを満たすために必要なメソッドを返します。
+// 空のインターフェースの場合は、wellKnownInterfacesのメソッドを返します。
+func interfaceMethods(t types.Type, wellKnownInterfaces []*types.Named) (methods []*types.Func) {
+	iface := t.Underlying().(*types.Interface)
+	if iface.Empty() {
+		for _, wellKnownInterface := range wellKnownInterfaces {
+			methods = append(methods, interfaceMethods(wellKnownInterface, nil)...)
+		}
+		return
+	}
+	for i := 0; i < iface.NumMethods(); i++ {
+		methods = append(methods, iface.Method(i))
+	}
+	return
+}
+
+// bundledNamedTypes はtと、tのポインタ・要素・エクスポートされたフィールドから辿れるバンドル対象の名前付きの型を返します。
+// fmtなどは値を出力する際に要素やフィールドのメソッドも呼び出すため、それらの型も対象とします。
+func (p *Packages) bundledNamedTypes(t types.Type, visited map[types.Type]bool) (named []*types.Named) {
+	if visited[t] {
+		return nil
+	}
+	visited[t] = true
+
+	switch u := types.Unalias(t).(type) {
+	case *types.Named:
+		if p.isBundledObject(u.Obj()) {
+			named = append(named, u)
+		}
+		return append(named, p.bundledNamedTypes(u.Underlying(), visited)...)
+	case *types.Pointer:
+		return p.bundledNamedTypes(u.Elem(), visited)
+	case *types.Slice:
+		return p.bundledNamedTypes(u.Elem(), visited)
+	case *types.Array:
+		return p.bundledNamedTypes(u.Elem(), visited)
+	case *types.Chan:
+		return p.bundledNamedTypes(u.Elem(), visited)
+	case *types.Map:
+		return append(p.bundledNamedTypes(u.Key(), visited), p.bundledNamedTypes(u.Elem(), visited)...)
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			if field := u.Field(i); field.Exported() {
+				named = append(named, p.bundledNamedTypes(field.Type(), visited)...)
+			}
+		}
+	}
+	return
+}
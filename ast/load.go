@@ -0,0 +1,137 @@
+package ast
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"os"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/mpppk/gollup/util"
+)
+
+// LoadConfig はパッケージを読み込む際のgoコマンドの設定です。
+// go.workやgo.modのreplaceディレクティブ、vendorディレクトリはgoコマンドと同様に解決されます。
+type LoadConfig struct {
+	// Dir はgoコマンドを実行するディレクトリです。空の場合はカレントディレクトリです
+	Dir string
+	// Mod はgoコマンドの-modフラグの値(mod, readonly, vendor)です。空の場合はgoコマンドのデフォルトに従います
+	Mod string
+	// WorkFile はGOWORKの値です。offを指定するとワークスペースを無効にします。空の場合はgoコマンドのデフォルトに従います
+	WorkFile string
+	// Tags はファイルを選択する際に満たされているとみなすビルドタグです
+	Tags []string
+	// GOOS, GOARCH はファイルを選択する際の対象のOSとアーキテクチャです。空の場合はgoコマンドのデフォルトに従います
+	GOOS   string
+	GOARCH string
+}
+
+// BuildConstraint は出力するファイルに付与する//go:buildの制約を返します。制約が不要な場合は空文字列を返します。
+// ビルドタグによって選択されたコードは出力に含まれているため、ビルドタグは制約に含めません。
+// OSやアーキテクチャに依存するコードは他の環境では動作しない可能性があるため、指定されたGOOSとGOARCHを制約とします。
+func (c *LoadConfig) BuildConstraint() string {
+	if c == nil {
+		return ""
+	}
+	var terms []string
+	for _, term := range []string{c.GOOS, c.GOARCH} {
+		if term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return strings.Join(terms, " && ")
+}
+
+func (c *LoadConfig) newPackagesConfig(fset *token.FileSet) (*packages.Config, error) {
+	config := &packages.Config{
+		Mode: packages.NeedCompiledGoFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedModule | packages.LoadAllSyntax,
+		Fset: fset,
+		Env:  append(os.Environ(), "GOPROXY=off"),
+	}
+	if c == nil {
+		return config, nil
+	}
+	config.Dir = c.Dir
+	switch c.Mod {
+	case "":
+	case "mod", "readonly", "vendor":
+		config.BuildFlags = append(config.BuildFlags, "-mod="+c.Mod)
+	default:
+		return nil, errors.New("invalid module mode: " + c.Mod)
+	}
+	if c.WorkFile != "" {
+		config.Env = append(config.Env, "GOWORK="+c.WorkFile)
+	}
+	if len(c.Tags) > 0 {
+		config.BuildFlags = append(config.BuildFlags, "-tags="+strings.Join(c.Tags, ","))
+	}
+	if c.GOOS != "" {
+		config.Env = append(config.Env, "GOOS="+c.GOOS)
+	}
+	if c.GOARCH != "" {
+		config.Env = append(config.Env, "GOARCH="+c.GOARCH)
+	}
+	return config, nil
+}
+
+// NewPackagesFromPackageNames は指定したパッケージと、それらから推移的にimportされているパッケージのうち
+// filterにマッチするものを読み込みます。ライブラリのパッケージを個別に指定する必要はありません。
+// サードパーティのモジュールはローカルのモジュールキャッシュから読み込み、ネットワークにはアクセスしません。
+func NewPackagesFromPackageNames(packageNames []string, conf *LoadConfig, filter *PackageFilter) (*Packages, *token.FileSet, error) {
+	fset := token.NewFileSet()
+	config, err := conf.newPackagesConfig(fset)
+	if err != nil {
+		return nil, nil, err
+	}
+	pkgs, err := packages.Load(config, packageNames...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := findMissingModuleError(pkgs); err != nil {
+		return nil, nil, err
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		return nil, nil, errors.New("error occurred in NewPackagesFromPackageNames")
+	}
+	return NewPackagesWithImports(pkgs, filter), fset, nil
+}
+
+// moduleLookupErrors はモジュールキャッシュに存在しないモジュールを探そうとした際のgoコマンドのエラーメッセージの一部です
+var moduleLookupErrors = []string{
+	"GOPROXY=off",
+	"missing go.sum entry",
+	"no required module provides package",
+	"cannot find module providing package",
+}
+
+// isModuleLookupError はエラーがモジュールの探索に失敗したことによるものかを返します
+func isModuleLookupError(e packages.Error) bool {
+	if e.Kind != packages.ListError {
+		return false
+	}
+	for _, msg := range moduleLookupErrors {
+		if strings.Contains(e.Msg, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// findMissingModuleError はimportされているパッケージのうち、モジュールキャッシュに存在せず読み込めなかったものがあればエラーを返します
+// パッケージのパスの誤りなど、モジュールの探索以外のエラーはnilを返し、呼び出し元で通常のエラーとして扱います
+func findMissingModuleError(pkgs []*packages.Package) (err error) {
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if err != nil || util.IsStandardPackage(pkg.PkgPath) || (pkg.Module != nil && pkg.Module.Main) {
+			return
+		}
+		for _, e := range pkg.Errors {
+			if isModuleLookupError(e) {
+				err = fmt.Errorf("failed to load %s from the module cache. run `go mod download` before bundling: %s", pkg.PkgPath, e.Msg)
+				return
+			}
+		}
+	})
+	return
+}
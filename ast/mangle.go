@@ -0,0 +1,249 @@
+package ast
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// MangleStrategy はバンドル対象のパッケージで宣言された名前を、バンドル後のファイルでの名前に変換する方法です
+type MangleStrategy string
+
+const (
+	// ManglePrefix は常にパッケージ名を付与します(lib_F)
+	ManglePrefix MangleStrategy = "prefix"
+	// MangleOnConflict は他の名前と衝突する場合のみパッケージ名を付与します
+	MangleOnConflict MangleStrategy = "conflict"
+	// MangleHash はインポートパスのハッシュ値の先頭を付与した短い名前にします(F_1a2b)
+	MangleHash MangleStrategy = "hash"
+)
+
+// MangleStrategies は指定可能なMangleStrategyの一覧です
+var MangleStrategies = []MangleStrategy{ManglePrefix, MangleOnConflict, MangleHash}
+
+// ParseMangleStrategy は文字列からMangleStrategyを返します。空文字列の場合はManglePrefixを返します。
+func ParseMangleStrategy(s string) (MangleStrategy, error) {
+	if s == "" {
+		return ManglePrefix, nil
+	}
+	for _, strategy := range MangleStrategies {
+		if string(strategy) == s {
+			return strategy, nil
+		}
+	}
+	return "", fmt.Errorf("invalid mangle strategy %q. it must be one of %q", s, MangleStrategies)
+}
+
+// Names はバンドル後のファイルでのパッケージレベルのobjectの名前です
+type Names struct {
+	names map[types.Object]string
+	// taken はパッケージレベルの名前として使われている、もしくは使えない名前です
+	taken map[string]bool
+}
+
+// Of はobjのバンドル後の名前を返します。renameされないobjectの場合は元の名前を返します。
+func (n *Names) Of(obj types.Object) string {
+	if f, ok := obj.(*types.Func); ok {
+		obj = f.Origin()
+	}
+	if name, ok := n.names[obj]; ok {
+		return name
+	}
+	return obj.Name()
+}
+
+// mangleEntry はバンドル後の名前を決める必要があるobjectと、その名前の候補です
+type mangleEntry struct {
+	obj        types.Object
+	candidates []string
+	level      int
+}
+
+// exhausted は異なるインポートパスのobjectと区別できる候補が残っていなければtrueを返します
+func (e *mangleEntry) exhausted() bool {
+	return e.level >= len(e.candidates)-1
+}
+
+func (e *mangleEntry) candidate() string {
+	if e.level < len(e.candidates) {
+		return e.candidates[e.level]
+	}
+	// 全ての候補が衝突した場合は、最後の候補に番号を付与する
+	return e.candidates[len(e.candidates)-1] + "_" + strconv.Itoa(e.level-len(e.candidates)+2)
+}
+
+// newNames はバンドル後のファイルで一意になるように、出力される全てのパッケージレベルのobjectの名前を決めます。
+// mainパッケージのobjectはrenameしません。それ以外のobjectは、名前が衝突する間はインポートパスのより多くの要素を付与した候補を順に試します。
+// 全ての候補が衝突するobject同士は、インポートパスの順で最初のobjectが候補を使い、残りのobjectは番号を付与した名前を試します。
+func (p *Program) newNames(strategy MangleStrategy) (*Names, error) {
+	taken := p.reservedNames()
+	names := &Names{names: map[types.Object]string{}, taken: taken}
+
+	var entries []*mangleEntry
+	add := func(obj types.Object, name string) {
+		if obj == nil || name == "_" {
+			return
+		}
+		if _, ok := names.names[obj]; ok {
+			return
+		}
+		if obj.Pkg().Name() == "main" && obj.Name() == name {
+			names.names[obj] = name
+			taken[name] = true
+			return
+		}
+		names.names[obj] = ""
+		entries = append(entries, &mangleEntry{obj: obj, candidates: mangleCandidates(strategy, obj.Pkg(), name)})
+	}
+	addByName := func(pkg *types.Package, name string) {
+		add(pkg.Scope().Lookup(name), name)
+	}
+
+	for _, obj := range p.ConstObjects {
+		add(obj, obj.Name())
+	}
+	for _, group := range p.ConstGroups {
+		var groupNames []string
+		for name := range group.usedNames {
+			groupNames = append(groupNames, name)
+		}
+		sort.Strings(groupNames)
+		for _, name := range groupNames {
+			addByName(group.Pkg, name)
+		}
+	}
+	for i, genDecl := range p.Vars {
+		for _, name := range genDecl.Specs[0].(*ast.ValueSpec).Names {
+			addByName(p.VarObjects[i].Pkg(), name.Name)
+		}
+	}
+	for _, obj := range p.TypeObjects {
+		add(obj, obj.Name())
+	}
+	initFuncs := map[types.Object]bool{}
+	counts := map[string]int{}
+	for _, f := range p.InitFuncs {
+		initFuncs[f] = true
+		add(f, fmt.Sprintf("init%d", counts[f.Pkg().Path()]))
+		counts[f.Pkg().Path()]++
+	}
+	for _, obj := range p.FuncObjects {
+		if initFuncs[obj] || obj.(*types.Func).Type().(*types.Signature).Recv() != nil {
+			continue
+		}
+		add(obj, obj.Name())
+	}
+
+	// 各ループで少なくとも1つのobjectの名前が決まるか、takenの名前を1つ避けるため、これを超える場合は終了しない
+	maxLoops := len(entries) + len(taken) + 1
+	for _, e := range entries {
+		maxLoops += len(e.candidates)
+	}
+	for loop := 0; len(entries) > 0; loop++ {
+		if loop >= maxLoops {
+			return nil, fmt.Errorf("failed to decide a unique name for %s.%s", entries[0].obj.Pkg().Path(), entries[0].obj.Name())
+		}
+		groups := map[string][]*mangleEntry{}
+		for _, e := range entries {
+			groups[e.candidate()] = append(groups[e.candidate()], e)
+		}
+		var conflicted []*mangleEntry
+		for _, e := range entries {
+			name := e.candidate()
+			group := groups[name]
+			if !taken[name] && (len(group) == 1 || (allExhausted(group) && e == firstByPath(group))) {
+				names.names[e.obj] = name
+				taken[name] = true
+				continue
+			}
+			conflicted = append(conflicted, e)
+		}
+		for _, e := range conflicted {
+			e.level++
+		}
+		entries = conflicted
+	}
+	return names, nil
+}
+
+// allExhausted は全てのentryに区別できる候補が残っていなければtrueを返します
+func allExhausted(entries []*mangleEntry) bool {
+	for _, e := range entries {
+		if !e.exhausted() {
+			return false
+		}
+	}
+	return true
+}
+
+// firstByPath はインポートパスの順で最初のobjectのentryを返します
+func firstByPath(entries []*mangleEntry) *mangleEntry {
+	first := entries[0]
+	for _, e := range entries[1:] {
+		if path, firstPath := e.obj.Pkg().Path(), first.obj.Pkg().Path(); path < firstPath || (path == firstPath && e.obj.Name() < first.obj.Name()) {
+			first = e
+		}
+	}
+	return first
+}
+
+// reservedNames はバンドル後のファイルでパッケージレベルの名前として使えない名前を返します。
+// 組み込みの名前と、バンドルされないパッケージを参照するためのパッケージ名が含まれます。
+func (p *Program) reservedNames() map[string]bool {
+	reserved := map[string]bool{"init": true}
+	for _, name := range types.Universe.Names() {
+		reserved[name] = true
+	}
+	for _, name := range p.imports.names {
+		reserved[name] = true
+	}
+	return reserved
+}
+
+// mangleCandidates はpkgで宣言されたnameのバンドル後の名前の候補を、優先する順に返します
+func mangleCandidates(strategy MangleStrategy, pkg *types.Package, name string) (candidates []string) {
+	if strategy == MangleHash {
+		sum := sha1.Sum([]byte(pkg.Path()))
+		hash := hex.EncodeToString(sum[:])
+		for _, n := range []int{4, 8, 16, len(hash)} {
+			candidates = append(candidates, name+"_"+hash[:n])
+		}
+		return
+	}
+
+	if strategy == MangleOnConflict {
+		candidates = append(candidates, name)
+	}
+	for _, prefix := range pathPrefixes(pkg) {
+		candidates = append(candidates, prefix+"_"+name)
+	}
+	return
+}
+
+// pathPrefixes はパッケージ名から始めて、インポートパスの要素を一つずつ前に付与した名前を返します(util, math_util, lib_math_util, ...)
+func pathPrefixes(pkg *types.Package) (prefixes []string) {
+	prefix := sanitizeIdent(pkg.Name())
+	prefixes = append(prefixes, prefix)
+	elems := strings.Split(pkg.Path(), "/")
+	for i := len(elems) - 2; i >= 0; i-- {
+		prefix = sanitizeIdent(elems[i]) + "_" + prefix
+		prefixes = append(prefixes, prefix)
+	}
+	return
+}
+
+// sanitizeIdent はsの識別子として使えない文字を_に置き換えます
+func sanitizeIdent(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return r
+		}
+		return '_'
+	}, s)
+}
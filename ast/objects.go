@@ -1,7 +1,6 @@
 package ast
 
 import (
-	"fmt"
 	"go/types"
 )
 
@@ -14,28 +13,27 @@ func findObject(objects []types.Object, object types.Object) (types.Object, bool
 	return nil, false
 }
 
+// isSameObject は二つのobjectが同じ宣言を指していればtrueを返します
 func isSameObject(obj1, obj2 types.Object) bool {
-	return getObjectUniqueStr(obj1) == getObjectUniqueStr(obj2)
+	return obj1 == obj2
 }
 
-func getObjectUniqueStr(obj types.Object) string {
-	recv := ""
-	switch t := obj.Type().(type) {
-	case *types.Signature:
-		if t.Recv() != nil {
-			recv = t.Recv().Type().String()
+// distinctObjects は重複したobjectを取り除きます。順序は最初に出現した順に保たれます
+func distinctObjects(objects []types.Object) (newObjects []types.Object) {
+	m := map[types.Object]bool{}
+	for _, object := range objects {
+		if m[object] {
+			continue
 		}
+		m[object] = true
+		newObjects = append(newObjects, object)
 	}
-	return fmt.Sprintf("%s:%s:%s", obj.Pkg(), recv, obj.Name())
+	return
 }
 
-func distinctObjects(objects []types.Object) (newObjects []types.Object) {
-	m := map[string]types.Object{}
-	for _, object := range objects {
-		m[getObjectUniqueStr(object)] = object
-	}
-	for _, object := range m {
-		newObjects = append(newObjects, object)
+func funcsToObjects(funcs []*types.Func) (objects []types.Object) {
+	for _, f := range funcs {
+		objects = append(objects, f)
 	}
 	return
 }
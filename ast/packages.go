@@ -10,6 +10,14 @@ import (
 
 type Packages struct {
 	Packages map[string]*packages.Package
+	// decls はパッケージレベルで宣言されたobjectから、その宣言への対応です
+	decls map[types.Object]*declaration
+}
+
+// declaration はobjectを宣言しているDeclと、GenDeclの場合はその中のSpecを表します
+type declaration struct {
+	decl ast.Decl
+	spec ast.Spec
 }
 
 func NewPackages(pkgs []*packages.Package) *Packages {
@@ -17,8 +25,59 @@ func NewPackages(pkgs []*packages.Package) *Packages {
 	for _, pkg := range pkgs {
 		m[pkg.PkgPath] = pkg
 	}
-	return &Packages{
+	p := &Packages{
 		Packages: m,
+		decls:    map[types.Object]*declaration{},
+	}
+	for _, pkg := range pkgs {
+		p.addDeclarations(pkg)
+	}
+	return p
+}
+
+// NewPackagesWithImports はpkgsと、pkgsから推移的にimportされているパッケージのうちfilterにマッチするものをバンドル対象とします。
+// filterにマッチしないパッケージからimportされているパッケージは辿りません。
+func NewPackagesWithImports(pkgs []*packages.Package, filter *PackageFilter) *Packages {
+	roots := map[*packages.Package]bool{}
+	for _, pkg := range pkgs {
+		roots[pkg] = true
+	}
+	var bundled []*packages.Package
+	packages.Visit(pkgs, func(pkg *packages.Package) bool {
+		if filter.IsKept(pkg.PkgPath) || (!roots[pkg] && !filter.Match(pkg.PkgPath)) {
+			return false
+		}
+		bundled = append(bundled, pkg)
+		return true
+	}, nil)
+	return NewPackages(bundled)
+}
+
+// addDeclarations はpkgのTypesInfo.Defsを元に、パッケージレベルで宣言されたobjectと宣言の対応を登録します
+func (p *Packages) addDeclarations(pkg *packages.Package) {
+	add := func(ident *ast.Ident, decl *declaration) {
+		if obj := pkg.TypesInfo.Defs[ident]; obj != nil {
+			p.decls[obj] = decl
+		}
+	}
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				add(d.Name, &declaration{decl: d})
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					switch s := spec.(type) {
+					case *ast.ValueSpec:
+						for _, name := range s.Names {
+							add(name, &declaration{decl: d, spec: s})
+						}
+					case *ast.TypeSpec:
+						add(s.Name, &declaration{decl: d, spec: s})
+					}
+				}
+			}
+		}
 	}
 }
 
@@ -31,115 +90,83 @@ func (p *Packages) FindPkgByName(name string) (*packages.Package, bool) {
 	return nil, false
 }
 
-func (p *Packages) ObjectsToDecls(objects []types.Object) []ast.Decl {
-	var decls []ast.Decl
-	for _, object := range objects {
-		decl := p.FindDeclByObject(object)
-		decls = append(decls, decl)
-	}
-	return decls
+func (p *Packages) getPkg(path string) *packages.Package {
+	return p.Packages[path]
 }
 
-func (p *Packages) getFuncFromSelectorExpr(currentPkgPath string, selExpr *ast.SelectorExpr) (*types.Func, bool) {
-	pkg := p.getPkg(currentPkgPath)
-
-	x, ok := selExpr.X.(*ast.Ident)
-	if !ok {
-		return nil, false
-	}
+// isBundled は指定したパスのパッケージがバンドル対象であればtrueを返します。
+// 標準パッケージや、importのまま残すパッケージはバンドル対象ではありません。
+func (p *Packages) isBundled(path string) bool {
+	return p.getPkg(path) != nil
+}
 
-	xObj := pkg.TypesInfo.ObjectOf(x)
-	if xObj == nil {
-		return nil, false
-	}
-	pkg2 := p.getPkg(xObj.Pkg().Path())
+// isBundledObject はobjectがバンドル対象のパッケージで宣言されていればtrueを返します
+func (p *Packages) isBundledObject(obj types.Object) bool {
+	return obj != nil && obj.Pkg() != nil && p.isBundled(obj.Pkg().Path())
+}
 
-	f, ok := getFuncFromIdent(pkg2, selExpr.Sel)
+// FindDeclByObject はobjectを宣言しているDeclを返します。
+// 読み込まれていないパッケージのobjectや、関数内で宣言されたobjectなど、パッケージレベルの宣言を持たない場合はnilを返します。
+func (p *Packages) FindDeclByObject(object types.Object) ast.Decl {
+	decl, ok := p.findDeclaration(object)
 	if !ok {
-		return nil, false
+		return nil
 	}
-	return f, ok
+	return decl.decl
 }
 
-func (p *Packages) getPkg(path string) *packages.Package {
-	return p.Packages[path]
+// findSpecByObject はobjectを宣言しているSpecを返します
+func (p *Packages) findSpecByObject(object types.Object) (ast.Spec, bool) {
+	decl, ok := p.findDeclaration(object)
+	if !ok || decl.spec == nil {
+		return nil, false
+	}
+	return decl.spec, true
 }
 
-func (p *Packages) FindDeclByObject(object types.Object) ast.Decl {
-	pkg := p.getPkg(object.Pkg().Path())
-
+func (p *Packages) findDeclaration(object types.Object) (*declaration, bool) {
+	// インスタンス化されたジェネリクスの関数は、宣言されている元の関数に置き換える
 	if f, ok := object.(*types.Func); ok {
-		return findFuncDeclByFuncType(pkg.Syntax, f)
+		object = f.Origin()
 	}
-
-	for _, file := range pkg.Syntax {
-		for _, decl := range file.Decls {
-			switch d := decl.(type) {
-			case *ast.GenDecl:
-				if d.Tok == token.IMPORT {
-					continue
-				}
-				for _, spec := range d.Specs {
-					switch s := spec.(type) {
-					case *ast.ImportSpec:
-						continue
-					case *ast.ValueSpec:
-						for _, name := range s.Names {
-							if name.Name == object.Name() {
-								return decl
-							}
-						}
-					case *ast.TypeSpec:
-						if s.Name.Name == object.Name() {
-							return decl
-						}
-					}
-				}
-			}
-		}
-	}
-	return nil
+	decl, ok := p.decls[object]
+	return decl, ok
 }
 
-func (p *Packages) findObject(pkgPath string, ident *ast.Ident) types.Object {
-	pkg := p.Packages[pkgPath]
-	return pkg.TypesInfo.ObjectOf(ident)
+// findTypeSpecByObject は型の宣言のうち、指定した型に対応するTypeSpecを返します
+func (p *Packages) findTypeSpecByObject(typeName *types.TypeName) (*ast.TypeSpec, bool) {
+	spec, ok := p.findSpecByObject(typeName)
+	if !ok {
+		return nil, false
+	}
+	typeSpec, ok := spec.(*ast.TypeSpec)
+	return typeSpec, ok
 }
 
-// 与えられたidentが参照しているDeclと所属しているパッケージ名を返します。
-func (p *Packages) findDeclOfIdent(pkgPath string, ident *ast.Ident) (string, ast.Decl) {
-	obj := p.findObject(pkgPath, ident)
-	targetPkgName := obj.Pkg().Name()
-	pkg := p.Packages[targetPkgName]
-	for _, file := range pkg.Syntax {
-		for _, decl := range file.Decls {
-			switch d := decl.(type) {
-			case *ast.GenDecl:
-				if d.Tok == token.IMPORT {
-					continue
-				}
-				for _, spec := range d.Specs {
-					switch s := spec.(type) {
-					case *ast.ImportSpec:
-						continue
-					case *ast.ValueSpec:
-						for _, name := range s.Names {
-							if name.Name == ident.Name {
-								return obj.Pkg().Name(), decl
-							}
-						}
-					case *ast.TypeSpec:
-						if s.Name.Name == ident.Name {
-							return targetPkgName, decl
-						}
-					}
-				}
-			case *ast.FuncDecl:
-				if d.Name.Name == ident.Name {
-					return targetPkgName, decl
-				}
-			}
+// findValueSpecsByObject は変数や定数の宣言のうち、指定したobjectの値を決めるValueSpecを返します。
+// iotaや暗黙の繰り返しを含む定数のグループの場合は、グループの先頭からobjectを宣言しているValueSpecまでを返します。
+func (p *Packages) findValueSpecsByObject(object types.Object) ([]*ast.ValueSpec, bool) {
+	genDecl, ok := p.FindDeclByObject(object).(*ast.GenDecl)
+	if !ok {
+		return nil, false
+	}
+	spec, ok := p.findSpecByObject(object)
+	if !ok {
+		return nil, false
+	}
+	valueSpec, ok := spec.(*ast.ValueSpec)
+	if !ok {
+		return nil, false
+	}
+	if genDecl.Tok != token.CONST || isSelfContainedConstDecl(genDecl, p.getPkg(object.Pkg().Path()).TypesInfo) {
+		return []*ast.ValueSpec{valueSpec}, true
+	}
+	var valueSpecs []*ast.ValueSpec
+	for _, spec := range genDecl.Specs {
+		valueSpecs = append(valueSpecs, spec.(*ast.ValueSpec))
+		if spec == valueSpec {
+			break
 		}
 	}
-	return "", nil
+	return valueSpecs, true
 }
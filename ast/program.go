@@ -4,6 +4,8 @@ import (
 	"go/ast"
 	"go/token"
 	"go/types"
+
+	"golang.org/x/tools/go/packages"
 )
 
 type Program struct {
@@ -14,22 +16,51 @@ type Program struct {
 	Imports       []*ast.GenDecl
 	ConstObjects  []types.Object
 	Const         *ast.GenDecl
+	ConstGroups   []*ConstGroup
 	TypeObjects   []types.Object
 	Types         []*ast.GenDecl
 	VarObjects    []types.Object
 	Vars          []*ast.GenDecl
 	FuncObjects   []types.Object
 	Funcs         []*ast.FuncDecl
+	// InitOrder はバンドル対象のパッケージの初期化順、InitFuncs はその順に呼び出されるinit関数です
+	InitOrder []*packages.Package
+	InitFuncs []*types.Func
+	// MangleStrategy はバンドル後の名前の決め方、Names はBundleで決まったバンドル後の名前です
+	MangleStrategy MangleStrategy
+	Names          *Names
+	imports        *importSet
+	// varInits はinit関数の中で行う変数の初期化の代入文です。キーはパッケージのインポートパスです
+	varInits map[string][]ast.Stmt
+}
+
+// ConstGroup はiotaや暗黙の繰り返しを含むため、元のグループの構造を保ったまま出力する定数宣言を表します
+type ConstGroup struct {
+	Pkg  *types.Package
+	Decl *ast.GenDecl
+	// 元の宣言と、その中で到達可能な定数の名前
+	origDecl  *ast.GenDecl
+	usedNames map[string]bool
 }
 
-func NewProgram(pkgs *Packages, objects []types.Object) *Program {
+func NewProgram(pkgs *Packages, objects []types.Object, initOrder []*packages.Package) *Program {
 	var decls []ast.Decl
+	var declObjects []types.Object
 	for _, object := range objects {
 		decl := pkgs.FindDeclByObject(object)
+		// インターフェースのメソッドなど、宣言を持たないobjectは出力しない
+		if decl == nil {
+			continue
+		}
 		decls = append(decls, decl)
+		declObjects = append(declObjects, object)
 	}
-	sdecls := &Program{Packages: pkgs, Decls: decls, Objects: objects}
+	objects = declObjects
+	sdecls := &Program{Packages: pkgs, Decls: decls, Objects: objects, InitOrder: initOrder, InitFuncs: InitFuncs(initOrder)}
 	constDecl := &ast.GenDecl{Tok: token.CONST}
+	usedVarNames := map[*ast.ValueSpec]map[string]bool{}
+	var varSpecs []*ast.ValueSpec
+	var varSpecObjects []types.Object
 	for i, decl := range decls {
 		switch d := decl.(type) {
 		case *ast.GenDecl:
@@ -39,12 +70,17 @@ func NewProgram(pkgs *Packages, objects []types.Object) *Program {
 				sdecls.ImportObjects = append(sdecls.ImportObjects, objects[i])
 			case token.CONST:
 				o := objects[i]
+				if !isSelfContainedConstDecl(d, pkgs.getPkg(o.Pkg().Path()).TypesInfo) {
+					sdecls.addToConstGroup(d, o)
+					continue
+				}
 				for _, spec := range d.Specs {
 					vspec := spec.(*ast.ValueSpec)
 					for i, name := range vspec.Names {
 						if o.Name() == name.Name {
 							constDecl.Specs = append(constDecl.Specs, &ast.ValueSpec{
 								Names:  []*ast.Ident{ast.NewIdent(name.Name)},
+								Type:   vspec.Type,
 								Values: []ast.Expr{vspec.Values[i]},
 							})
 						}
@@ -52,11 +88,28 @@ func NewProgram(pkgs *Packages, objects []types.Object) *Program {
 				}
 				sdecls.ConstObjects = append(sdecls.ConstObjects, objects[i])
 			case token.TYPE:
-				sdecls.Types = append(sdecls.Types, d)
+				// 同じグループで宣言されている型のうち、到達可能なものだけを出力する
+				typeSpec, ok := pkgs.findTypeSpecByObject(objects[i].(*types.TypeName))
+				if !ok {
+					continue
+				}
+				sdecls.Types = append(sdecls.Types, &ast.GenDecl{Tok: token.TYPE, Specs: []ast.Spec{typeSpec}})
 				sdecls.TypeObjects = append(sdecls.TypeObjects, objects[i])
 			case token.VAR:
-				sdecls.Vars = append(sdecls.Vars, d)
-				sdecls.VarObjects = append(sdecls.VarObjects, objects[i])
+				spec, ok := pkgs.findSpecByObject(objects[i])
+				if !ok {
+					continue
+				}
+				vspec, ok := spec.(*ast.ValueSpec)
+				if !ok {
+					continue
+				}
+				if _, ok := usedVarNames[vspec]; !ok {
+					usedVarNames[vspec] = map[string]bool{}
+					varSpecs = append(varSpecs, vspec)
+					varSpecObjects = append(varSpecObjects, objects[i])
+				}
+				usedVarNames[vspec][objects[i].Name()] = true
 			}
 		case *ast.FuncDecl:
 			sdecls.Funcs = append(sdecls.Funcs, d)
@@ -66,38 +119,189 @@ func NewProgram(pkgs *Packages, objects []types.Object) *Program {
 	if len(constDecl.Specs) > 0 {
 		sdecls.Const = constDecl
 	}
+	for _, group := range sdecls.ConstGroups {
+		group.Decl = newConstGroupDecl(group.origDecl, group.usedNames)
+	}
+	for i, vspec := range varSpecs {
+		for _, varDecl := range newVarDecls(vspec, usedVarNames[vspec]) {
+			sdecls.Vars = append(sdecls.Vars, varDecl)
+			sdecls.VarObjects = append(sdecls.VarObjects, varSpecObjects[i])
+		}
+	}
 	return sdecls
 }
 
-func (p *Program) Bundle(files []*ast.File) *ast.File {
+// newVarDecls は変数の宣言を、到達可能な変数だけを宣言するGenDeclに分割して返します。
+// x, y = f() のように複数の値を返す関数で初期化されている場合は分割できないため、到達可能でない変数の名前を_に置き換えます。
+func newVarDecls(vspec *ast.ValueSpec, usedNames map[string]bool) (decls []*ast.GenDecl) {
+	newVarDecl := func(spec *ast.ValueSpec) *ast.GenDecl {
+		return &ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{spec}}
+	}
+	if len(vspec.Values) > 0 && len(vspec.Values) != len(vspec.Names) {
+		var names []*ast.Ident
+		for _, name := range vspec.Names {
+			if usedNames[name.Name] {
+				names = append(names, ast.NewIdent(name.Name))
+			} else {
+				names = append(names, ast.NewIdent("_"))
+			}
+		}
+		return []*ast.GenDecl{newVarDecl(&ast.ValueSpec{Names: names, Type: vspec.Type, Values: vspec.Values})}
+	}
+	for i, name := range vspec.Names {
+		if !usedNames[name.Name] {
+			continue
+		}
+		spec := &ast.ValueSpec{Names: []*ast.Ident{ast.NewIdent(name.Name)}, Type: vspec.Type}
+		if len(vspec.Values) > 0 {
+			spec.Values = []ast.Expr{vspec.Values[i]}
+		}
+		decls = append(decls, newVarDecl(spec))
+	}
+	return
+}
+
+func (p *Program) addToConstGroup(genDecl *ast.GenDecl, object types.Object) {
+	for _, group := range p.ConstGroups {
+		if group.origDecl == genDecl {
+			group.usedNames[object.Name()] = true
+			return
+		}
+	}
+	p.ConstGroups = append(p.ConstGroups, &ConstGroup{
+		Pkg:       object.Pkg(),
+		origDecl:  genDecl,
+		usedNames: map[string]bool{object.Name(): true},
+	})
+}
+
+// newConstGroupDecl は元のグループの構造を保ったまま、到達可能でない定数の名前を_に置き換えた宣言を返します。
+// iotaの値を変えないように、最後に到達可能な定数を含むspecまでを出力します。
+func newConstGroupDecl(genDecl *ast.GenDecl, usedNames map[string]bool) *ast.GenDecl {
+	var specs []ast.Spec
+	last := 0
+	for _, spec := range genDecl.Specs {
+		vspec := spec.(*ast.ValueSpec)
+		var names []*ast.Ident
+		for _, name := range vspec.Names {
+			if usedNames[name.Name] {
+				names = append(names, ast.NewIdent(name.Name))
+				last = len(specs) + 1
+			} else {
+				names = append(names, ast.NewIdent("_"))
+			}
+		}
+		specs = append(specs, &ast.ValueSpec{Names: names, Type: vspec.Type, Values: vspec.Values})
+	}
+	return &ast.GenDecl{Tok: token.CONST, Lparen: 1, Specs: specs[:last]}
+}
+
+// isSelfContainedConstDecl は全てのspecが値を持ち、iotaを使用していない場合にtrueを返します。
+// このような定数は元のグループから取り出して個別に出力しても値や型が変わりません。
+func isSelfContainedConstDecl(genDecl *ast.GenDecl, info *types.Info) bool {
+	for _, spec := range genDecl.Specs {
+		vspec := spec.(*ast.ValueSpec)
+		if len(vspec.Values) == 0 {
+			return false
+		}
+		usesIota := false
+		for _, value := range vspec.Values {
+			ast.Inspect(value, func(node ast.Node) bool {
+				if ident, ok := node.(*ast.Ident); ok && info.Uses[ident] == types.Universe.Lookup("iota") {
+					usesIota = true
+				}
+				return !usesIota
+			})
+		}
+		if usesIota {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Program) Bundle() (*ast.File, error) {
+	p.imports = p.newImportSet()
+	names, err := p.newNames(p.MangleStrategy)
+	if err != nil {
+		return nil, err
+	}
+	p.Names = names
+	p.renameShadowingLocals()
+	if err := p.deferVarInitializers(); err != nil {
+		return nil, err
+	}
+
 	// rename functions
 	p.renameExternalPackageFunctions()
+	initFuncDecl := p.renameInitFuncs()
 	removeCommentsFromFuncDecls(p.Funcs)
 	renamedFuncDecls := CopyFuncDeclsAsDecl(p.Funcs)
+	if initFuncDecl != nil {
+		renamedFuncDecls = append(renamedFuncDecls, initFuncDecl)
+	}
 	renamedFuncDecls = SortFuncDeclsFromDecls(renamedFuncDecls)
 
+	p.renameExternalPackageValues()
 	p.addPackagePrefixToConst()
+	p.addPackagePrefixToVars()
+	p.renameExternalPackageTypes()
 
 	// rename consts
 	if p.Const != nil {
 		sortSpecs(p.Const.Specs)
 	}
-	SortGenDecls(p.Vars)
+	constGroupDecls := p.constGroupDecls()
+	SortGenDecls(constGroupDecls)
+	p.sortVarsByInitOrder()
 	SortGenDecls(p.Types)
 
-	file := newMergedFileFromPackageInfo(files)
+	if p.Const != nil {
+		removeComments(p.Const)
+	}
+	for _, genDecl := range append(append(constGroupDecls, p.Vars...), p.Types...) {
+		removeComments(genDecl)
+	}
+
+	file := &ast.File{Name: ast.NewIdent("main")}
+	if importDecl := p.imports.decl(); importDecl != nil {
+		file.Decls = append(file.Decls, importDecl)
+	}
 	if p.Const != nil {
 		file.Decls = append(file.Decls, p.Const)
 	}
+	file.Decls = append(file.Decls, GenDeclToDecl(constGroupDecls)...)
 	file.Decls = append(file.Decls, GenDeclToDecl(p.Vars)...)
 	file.Decls = append(file.Decls, GenDeclToDecl(p.Types)...)
 	file.Decls = append(file.Decls, renamedFuncDecls...)
-	return file
+	return file, nil
+}
+
+// declNodes は出力される宣言と、それぞれが宣言されているパッケージを返します
+func (p *Program) declNodes() (nodes []ast.Node, pkgs []*types.Package) {
+	for i, funcDecl := range p.Funcs {
+		nodes, pkgs = append(nodes, funcDecl), append(pkgs, p.FuncObjects[i].Pkg())
+	}
+	for i, genDecl := range p.Types {
+		nodes, pkgs = append(nodes, genDecl), append(pkgs, p.TypeObjects[i].Pkg())
+	}
+	for i, genDecl := range p.Vars {
+		nodes, pkgs = append(nodes, genDecl), append(pkgs, p.VarObjects[i].Pkg())
+	}
+	if p.Const != nil {
+		for i, spec := range p.Const.Specs {
+			nodes, pkgs = append(nodes, spec), append(pkgs, p.ConstObjects[i].Pkg())
+		}
+	}
+	for _, group := range p.ConstGroups {
+		nodes, pkgs = append(nodes, group.Decl), append(pkgs, group.Pkg)
+	}
+	return
 }
 
 func (p *Program) findDeclFromObject(object types.Object) (ast.Decl, bool) {
 	for i, o := range p.Objects {
-		if o.Id() == object.Id() {
+		if isSameObject(o, object) {
 			return p.Decls[i], true
 		}
 	}
@@ -108,19 +312,75 @@ func (p *Program) renameExternalPackageFunctions() {
 	for i, funcDecl := range p.Funcs {
 		object := p.FuncObjects[i]
 		pkg := p.Packages.getPkg(object.Pkg().Path())
-		renameExternalPackageFunction(funcDecl, object, pkg)
-		renameExternalPackageConst(funcDecl, pkg)
+		p.renameExternalPackageFunction(funcDecl, object, pkg)
+	}
+}
+
+// renameExternalPackageTypes は型の名前と、型の宣言中で参照されている型や定数をパッケージ名を付与した名前にrenameします。
+func (p *Program) renameExternalPackageTypes() {
+	for i, genDecl := range p.Types {
+		object := p.TypeObjects[i]
+		p.renameReferences(genDecl, p.Packages.getPkg(object.Pkg().Path()))
+		typeSpec := genDecl.Specs[0].(*ast.TypeSpec)
+		typeSpec.Name = ast.NewIdent(p.Names.Of(object))
+	}
+}
+
+// renameExternalPackageValues は定数や変数の宣言の型と初期化式で参照されている関数・型・定数をrenameします。
+func (p *Program) renameExternalPackageValues() {
+	if p.Const != nil {
+		for i, spec := range p.Const.Specs {
+			pkg := p.Packages.getPkg(p.ConstObjects[i].Pkg().Path())
+			p.renameReferences(spec, pkg)
+		}
+	}
+	for _, group := range p.ConstGroups {
+		pkg := p.Packages.getPkg(group.Pkg.Path())
+		p.renameReferences(group.Decl, pkg)
+	}
+	for i, genDecl := range p.Vars {
+		pkg := p.Packages.getPkg(p.VarObjects[i].Pkg().Path())
+		p.renameReferences(genDecl, pkg)
+	}
+	for path, stmts := range p.varInits {
+		for _, stmt := range stmts {
+			p.renameReferences(stmt, p.Packages.getPkg(path))
+		}
 	}
 }
 
 func (p *Program) addPackagePrefixToConst() {
-	if p.Const == nil {
-		return
+	if p.Const != nil {
+		for i, spec := range p.Const.Specs {
+			spec.(*ast.ValueSpec).Names[0].Name = p.Names.Of(p.ConstObjects[i])
+		}
+	}
+	for _, group := range p.ConstGroups {
+		for _, spec := range group.Decl.Specs {
+			for _, name := range spec.(*ast.ValueSpec).Names {
+				if name.Name != "_" {
+					name.Name = p.Names.Of(group.Pkg.Scope().Lookup(name.Name))
+				}
+			}
+		}
 	}
-	for i, spec := range p.Const.Specs {
-		pkgName := p.ConstObjects[i].Pkg().Name()
-		if pkgName != "main" { // FIXME
-			addPrefixToSpec(spec, pkgName+"_")
+}
+
+func (p *Program) addPackagePrefixToVars() {
+	for i, genDecl := range p.Vars {
+		for _, spec := range genDecl.Specs {
+			for _, name := range spec.(*ast.ValueSpec).Names {
+				if name.Name != "_" {
+					name.Name = p.Names.Of(p.VarObjects[i].Pkg().Scope().Lookup(name.Name))
+				}
+			}
 		}
 	}
 }
+
+func (p *Program) constGroupDecls() (decls []*ast.GenDecl) {
+	for _, group := range p.ConstGroups {
+		decls = append(decls, group.Decl)
+	}
+	return
+}
@@ -0,0 +1,190 @@
+package ast
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+)
+
+// typeExpr はバンドル後のファイルでtを表す型の式を返します。
+// バンドル対象のパッケージの型はバンドル後の名前で、バンドルされないパッケージの型はimportで付けた名前で修飾して参照します。
+// バンドル後のファイルで参照できない型の場合はエラーを返します。
+func (p *Program) typeExpr(t types.Type) (ast.Expr, error) {
+	switch t := t.(type) {
+	case *types.Basic:
+		return ast.NewIdent(t.Name()), nil
+	case *types.Named:
+		return p.namedTypeExpr(t.Obj(), t.TypeArgs())
+	case *types.Alias:
+		return p.typeExpr(types.Unalias(t))
+	case *types.Pointer:
+		elem, err := p.typeExpr(t.Elem())
+		return &ast.StarExpr{X: elem}, err
+	case *types.Slice:
+		elem, err := p.typeExpr(t.Elem())
+		return &ast.ArrayType{Elt: elem}, err
+	case *types.Array:
+		elem, err := p.typeExpr(t.Elem())
+		return &ast.ArrayType{Len: &ast.BasicLit{Kind: token.INT, Value: strconv.FormatInt(t.Len(), 10)}, Elt: elem}, err
+	case *types.Map:
+		key, err := p.typeExpr(t.Key())
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.typeExpr(t.Elem())
+		return &ast.MapType{Key: key, Value: value}, err
+	case *types.Chan:
+		elem, err := p.typeExpr(t.Elem())
+		dir := map[types.ChanDir]ast.ChanDir{types.SendRecv: ast.SEND | ast.RECV, types.SendOnly: ast.SEND, types.RecvOnly: ast.RECV}[t.Dir()]
+		return &ast.ChanType{Dir: dir, Value: elem}, err
+	case *types.Signature:
+		return p.funcTypeExpr(t)
+	case *types.Struct:
+		fields := &ast.FieldList{}
+		for i := 0; i < t.NumFields(); i++ {
+			field := t.Field(i)
+			fieldType, err := p.typeExpr(field.Type())
+			if err != nil {
+				return nil, err
+			}
+			f := &ast.Field{Type: fieldType}
+			if !field.Embedded() {
+				f.Names = []*ast.Ident{ast.NewIdent(field.Name())}
+			}
+			if tag := t.Tag(i); tag != "" {
+				f.Tag = &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(tag)}
+			}
+			fields.List = append(fields.List, f)
+		}
+		return &ast.StructType{Fields: fields}, nil
+	case *types.Interface:
+		methods := &ast.FieldList{}
+		for i := 0; i < t.NumEmbeddeds(); i++ {
+			embedded, err := p.typeExpr(t.EmbeddedType(i))
+			if err != nil {
+				return nil, err
+			}
+			methods.List = append(methods.List, &ast.Field{Type: embedded})
+		}
+		for i := 0; i < t.NumExplicitMethods(); i++ {
+			method := t.ExplicitMethod(i)
+			funcType, err := p.funcTypeExpr(method.Type().(*types.Signature))
+			if err != nil {
+				return nil, err
+			}
+			methods.List = append(methods.List, &ast.Field{Names: []*ast.Ident{ast.NewIdent(method.Name())}, Type: funcType})
+		}
+		return &ast.InterfaceType{Methods: methods}, nil
+	}
+	return nil, fmt.Errorf("type %s cannot be written in the bundle", t)
+}
+
+// namedTypeExpr は名前の付いた型を参照する式を返します
+func (p *Program) namedTypeExpr(obj *types.TypeName, typeArgs *types.TypeList) (ast.Expr, error) {
+	var expr ast.Expr
+	switch {
+	case obj.Pkg() == nil:
+		// errorなどの組み込みの型
+		expr = ast.NewIdent(obj.Name())
+	case p.Packages.isBundledObject(obj):
+		if _, ok := p.Names.names[obj]; !ok {
+			return nil, fmt.Errorf("type %s.%s is not included in the bundle", obj.Pkg().Path(), obj.Name())
+		}
+		expr = ast.NewIdent(p.Names.Of(obj))
+	case obj.Exported():
+		expr = &ast.SelectorExpr{X: ast.NewIdent(p.imports.names[obj.Pkg().Path()]), Sel: ast.NewIdent(obj.Name())}
+	default:
+		return nil, fmt.Errorf("unexported type %s.%s cannot be referenced from the bundle", obj.Pkg().Path(), obj.Name())
+	}
+	if typeArgs.Len() == 0 {
+		return expr, nil
+	}
+	var indices []ast.Expr
+	for i := 0; i < typeArgs.Len(); i++ {
+		index, err := p.typeExpr(typeArgs.At(i))
+		if err != nil {
+			return nil, err
+		}
+		indices = append(indices, index)
+	}
+	return &ast.IndexListExpr{X: expr, Indices: indices}, nil
+}
+
+// funcTypeExpr は関数の型を表す式を返します
+func (p *Program) funcTypeExpr(sig *types.Signature) (*ast.FuncType, error) {
+	fieldList := func(tuple *types.Tuple, variadic bool) (*ast.FieldList, error) {
+		list := &ast.FieldList{}
+		for i := 0; i < tuple.Len(); i++ {
+			var t ast.Expr
+			if variadic && i == tuple.Len()-1 {
+				elem, err := p.typeExpr(tuple.At(i).Type().(*types.Slice).Elem())
+				if err != nil {
+					return nil, err
+				}
+				t = &ast.Ellipsis{Elt: elem}
+			} else {
+				var err error
+				if t, err = p.typeExpr(tuple.At(i).Type()); err != nil {
+					return nil, err
+				}
+			}
+			list.List = append(list.List, &ast.Field{Type: t})
+		}
+		return list, nil
+	}
+	params, err := fieldList(sig.Params(), sig.Variadic())
+	if err != nil {
+		return nil, err
+	}
+	results, err := fieldList(sig.Results(), false)
+	if err != nil {
+		return nil, err
+	}
+	return &ast.FuncType{Params: params, Results: results}, nil
+}
+
+// externalTypePackages はtの中で参照されている、バンドルされないパッケージを返します
+func (p *Packages) externalTypePackages(t types.Type) (pkgs []*types.Package) {
+	var walk func(t types.Type)
+	walkTuple := func(tuple *types.Tuple) {
+		for i := 0; i < tuple.Len(); i++ {
+			walk(tuple.At(i).Type())
+		}
+	}
+	walk = func(t types.Type) {
+		switch t := t.(type) {
+		case *types.Alias:
+			walk(types.Unalias(t))
+		case *types.Named:
+			if obj := t.Obj(); obj.Pkg() != nil && !p.isBundledObject(obj) {
+				pkgs = append(pkgs, obj.Pkg())
+			}
+			for i := 0; i < t.TypeArgs().Len(); i++ {
+				walk(t.TypeArgs().At(i))
+			}
+		case interface{ Elem() types.Type }:
+			if m, ok := t.(*types.Map); ok {
+				walk(m.Key())
+			}
+			walk(t.Elem())
+		case *types.Signature:
+			walkTuple(t.Params())
+			walkTuple(t.Results())
+		case *types.Struct:
+			for i := 0; i < t.NumFields(); i++ {
+				walk(t.Field(i).Type())
+			}
+		case *types.Interface:
+			for i := 0; i < t.NumEmbeddeds(); i++ {
+				walk(t.EmbeddedType(i))
+			}
+			for i := 0; i < t.NumExplicitMethods(); i++ {
+				walk(t.ExplicitMethod(i).Type())
+			}
+		}
+	}
+	walk(t)
+	return
+}
@@ -2,55 +2,11 @@ package ast
 
 import (
 	"go/ast"
-	"go/token"
-	"go/types"
 	"sort"
 
-	"golang.org/x/tools/go/packages"
-
 	"github.com/go-toolsmith/astcopy"
 )
 
-func getFuncFromIdent(pkg *packages.Package, ident *ast.Ident) (*types.Func, bool) {
-	obj := pkg.TypesInfo.ObjectOf(ident)
-	if obj == nil {
-		return nil, false
-	}
-	f, ok := obj.(*types.Func)
-	return f, ok
-}
-
-func mergeImportDecls(files []*ast.File) (importDecl *ast.GenDecl) {
-	for _, file := range files {
-		imports := selectGenDeclsFromDecls(file.Decls, token.IMPORT)
-		if importDecl == nil {
-			importDecl = imports[0]
-		}
-		for _, genDecl := range imports {
-			importDecl.Specs = append(importDecl.Specs, genDecl.Specs...)
-		}
-	}
-	return
-}
-
-func selectGenDeclsFromDecls(decls []ast.Decl, tkn token.Token) (importDecls []*ast.GenDecl) {
-	for _, decl := range decls {
-		if importDecl, ok := declToGenDecl(decl, tkn); ok {
-			importDecls = append(importDecls, importDecl)
-		}
-	}
-	return
-}
-
-func declToGenDecl(decl ast.Decl, tkn token.Token) (*ast.GenDecl, bool) {
-	if genDecl, ok := decl.(*ast.GenDecl); ok {
-		if genDecl.Tok == tkn {
-			return genDecl, true
-		}
-	}
-	return nil, false
-}
-
 func GenDeclToDecl(genDecls []*ast.GenDecl) (decls []ast.Decl) {
 	for _, decl := range genDecls {
 		decls = append(decls, decl)
@@ -81,22 +37,28 @@ func CopyFuncDeclsAsDecl(funcDecls []*ast.FuncDecl) (newFuncDecls []ast.Decl) {
 	return
 }
 
-func renameFunc(pkg *types.Package, name string) string {
-	// universe const like true/false should not be renamed
-	if pkg == nil || pkg.Name() == "main" || types.Universe.Lookup(name) != nil {
-		return name
-	}
-	return pkg.Name() + "_" + name
-}
-
 func SortGenDecls(genDecls []*ast.GenDecl) {
 	sort.Slice(genDecls, func(i, j int) bool {
-		spec1 := genDecls[i].Specs[0]
-		spec2 := genDecls[j].Specs[0]
-		return specToString(spec1) < specToString(spec2)
+		return genDeclToString(genDecls[i]) < genDeclToString(genDecls[j])
 	})
 }
 
+// genDeclToString は_でない最初の名前を返します
+func genDeclToString(genDecl *ast.GenDecl) string {
+	for _, spec := range genDecl.Specs {
+		vspec, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			return specToString(spec)
+		}
+		for _, name := range vspec.Names {
+			if name.Name != "_" {
+				return name.Name
+			}
+		}
+	}
+	return ""
+}
+
 func sortSpecs(specs []ast.Spec) {
 	// FIXME: sort ValueSpecs names
 	sort.Slice(specs, func(i, j int) bool {
@@ -116,20 +78,6 @@ func specToString(spec ast.Spec) string {
 	return ""
 }
 
-// specの名前に指定したprefixを追加します。破壊的メソッドです。
-func addPrefixToSpec(spec ast.Spec, prefix string) {
-	switch s := spec.(type) {
-	case *ast.ImportSpec:
-		s.Name.Name = prefix + s.Name.Name
-	case *ast.ValueSpec:
-		for i, name := range s.Names {
-			s.Names[i].Name = prefix + name.Name
-		}
-	case *ast.TypeSpec:
-		s.Name.Name = prefix + s.Name.Name
-	}
-}
-
 func SortFuncDeclsFromDecls(decls []ast.Decl) []ast.Decl {
 	funcDecls := declToFuncDecl(decls)
 	getRecvName := func(funcDecl *ast.FuncDecl) (recv string) {
@@ -140,7 +88,7 @@ func SortFuncDeclsFromDecls(decls []ast.Decl) []ast.Decl {
 			for _, name := range field.Names {
 				recv += name.Name
 			}
-			expr := unwrapStarExpr(field.Type)
+			expr := unwrapIndexExpr(unwrapStarExpr(field.Type))
 			if ident, ok := expr.(*ast.Ident); ok {
 				recv += ident.Name
 			}
@@ -153,62 +101,6 @@ func SortFuncDeclsFromDecls(decls []ast.Decl) []ast.Decl {
 	return funcDeclToDecl(funcDecls)
 }
 
-// findFuncDeclByFuncType は指定された名前の関数をfilesから検索して返す。なければnil
-func findFuncDeclByFuncType(files []*ast.File, f *types.Func) (funcDecl *ast.FuncDecl) {
-	sig := f.Type().(*types.Signature)
-	for _, file := range files {
-		for _, decl := range file.Decls {
-			if funcDecl, ok := decl.(*ast.FuncDecl); ok {
-				if funcDecl.Name.Name != f.Name() {
-					continue
-				}
-
-				if funcDecl.Recv == nil {
-					if sig.Recv() != nil {
-						continue
-					} else {
-						return funcDecl
-					}
-				} else {
-					t := funcDecl.Recv.List[0].Type
-					if sig.Recv() == nil {
-						continue
-					}
-					recv := sig.Recv()
-					switch expr := t.(type) {
-					case *ast.StarExpr:
-						if expr.X.(*ast.Ident).Name == getRecvTypeName(recv) {
-							return funcDecl
-						}
-					case *ast.Ident:
-						if expr.Name == getRecvTypeName(recv) {
-							return funcDecl
-						}
-					}
-				}
-			}
-		}
-	}
-	return nil
-}
-
-func getRecvTypeName(recv *types.Var) string {
-	switch recvType := recv.Type().(type) {
-	case *types.Pointer:
-		recvNewType := unwrapPointer(recvType)
-		switch t := recvNewType.(type) {
-		case *types.Named:
-			return t.Obj().Name()
-		default:
-			panic("unknown type in getRecvTypeName: " + t.String())
-		}
-	case *types.Named:
-		return recvType.Obj().Name()
-	default:
-		panic("unknown recv type in getRecvTypeName: " + recvType.String())
-	}
-}
-
 func unwrapStarExpr(expr ast.Expr) ast.Expr {
 	cnt := 0
 	for {
@@ -225,20 +117,39 @@ func unwrapStarExpr(expr ast.Expr) ast.Expr {
 	}
 }
 
-func unwrapPointer(ptr *types.Pointer) (retType types.Type) {
-	for {
-		if p, ok := ptr.Elem().(*types.Pointer); ok {
-			ptr = p
-		} else {
-			retType = ptr.Elem()
-			break
-		}
+// unwrapIndexExpr はSegTree[T]やPair[K, V]のような型パラメータ付きの式から型パラメータを取り除きます
+func unwrapIndexExpr(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.IndexExpr:
+		return e.X
+	case *ast.IndexListExpr:
+		return e.X
 	}
-	return
+	return expr
 }
 
 func removeCommentsFromFuncDecls(funcDecls []*ast.FuncDecl) {
 	for _, decl := range funcDecls {
-		decl.Doc = nil
-	}
+		removeComments(decl)
+	}
+}
+
+// removeComments はnode中の宣言やフィールドに付与されたコメントを削除します。破壊的メソッドです。
+// 出力するファイルは元のコメントの位置を保持しないため、コメントが残っていると不正な位置に出力されます。
+func removeComments(node ast.Node) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch t := n.(type) {
+		case *ast.FuncDecl:
+			t.Doc = nil
+		case *ast.GenDecl:
+			t.Doc = nil
+		case *ast.Field:
+			t.Doc, t.Comment = nil, nil
+		case *ast.ValueSpec:
+			t.Doc, t.Comment = nil, nil
+		case *ast.TypeSpec:
+			t.Doc, t.Comment = nil, nil
+		}
+		return true
+	})
 }
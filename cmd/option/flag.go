@@ -13,6 +13,12 @@ type StringFlag struct {
 	IsFileName bool
 }
 
+// StringSliceFlag represents flag which can be specified as string slice
+type StringSliceFlag struct {
+	*BaseFlag
+	Value []string
+}
+
 // BoolFlag represents flag which can be specified as bool
 type BoolFlag struct {
 	*BaseFlag
@@ -110,6 +116,8 @@ func RegisterFlag(cmd *cobra.Command, flag Flag) error {
 	switch f := flag.(type) {
 	case *StringFlag:
 		rerr = RegisterStringFlag(cmd, f)
+	case *StringSliceFlag:
+		rerr = RegisterStringSliceFlag(cmd, f)
 	case *BoolFlag:
 		rerr = RegisterBoolFlag(cmd, f)
 	case *IntFlag:
@@ -164,6 +172,17 @@ func RegisterStringFlag(cmd *cobra.Command, flagConfig *StringFlag) error {
 	return markAttributes(cmd, flagConfig)
 }
 
+// RegisterStringSliceFlag register string slice flag to provided cmd and viper
+func RegisterStringSliceFlag(cmd *cobra.Command, flagConfig *StringSliceFlag) error {
+	flagSet := getFlagSet(cmd, flagConfig.BaseFlag)
+	if flagConfig.Shorthand == "" {
+		flagSet.StringSlice(flagConfig.Name, flagConfig.Value, flagConfig.Usage)
+	} else {
+		flagSet.StringSliceP(flagConfig.Name, flagConfig.Shorthand, flagConfig.Value, flagConfig.Usage)
+	}
+	return nil
+}
+
 // RegisterBoolFlag register bool flag to provided cmd and viper
 func RegisterBoolFlag(cmd *cobra.Command, flagConfig *BoolFlag) error {
 	flagSet := getFlagSet(cmd, flagConfig.BaseFlag)
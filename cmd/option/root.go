@@ -17,8 +17,20 @@ type RootCmdConfig struct {
 
 // RootCmdConfig is config for root command
 type RootRawCmdConfig struct {
-	Verbose    bool
-	EntryPoint string
+	Verbose             bool
+	EntryPoint          string
+	WellKnownInterfaces []string `mapstructure:"well-known-interfaces"`
+	Include             []string
+	Exclude             []string
+	KeepImports         []string `mapstructure:"keep-imports"`
+	Dir                 string
+	Mod                 string
+	WorkFile            string
+	Tags                []string
+	GOOS                string
+	GOARCH              string
+	EmbedSizeWarning    int `mapstructure:"embed-size-warning"`
+	Mangle              string
 }
 
 // NewRootCmdConfigFromViper generate config for sum command from viper
@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"go/format"
 	"go/token"
-	"go/types"
 	"io"
+	"log"
 	"os"
 
 	"github.com/pkg/errors"
@@ -55,28 +55,43 @@ func NewRootCmd(fs afero.Fs) (*cobra.Command, error) {
 				pkgDirs = []string{"."}
 			}
 
-			pkgs, _, err := ast2.NewPackagesFromPackageNames(pkgDirs)
+			mangleStrategy, err := ast2.ParseMangleStrategy(conf.Mangle)
 			if err != nil {
 				return err
 			}
 
-			pkg, ok := pkgs.FindPkgByName(conf.TargetPackage)
-			if !ok {
-				panic("specified packages does not found: " + conf.TargetPackage)
+			filter := &ast2.PackageFilter{Include: conf.Include, Exclude: conf.Exclude, KeepImports: conf.KeepImports}
+			loadConf := &ast2.LoadConfig{
+				Dir:      conf.Dir,
+				Mod:      conf.Mod,
+				WorkFile: conf.WorkFile,
+				Tags:     conf.Tags,
+				GOOS:     conf.GOOS,
+				GOARCH:   conf.GOARCH,
 			}
-
-			targetPkg, ok := pkg.Types.Scope().Lookup(conf.TargetMethod).(*types.Func)
-			if !ok {
-				panic("target is not func: " + conf.TargetPackage + "." + conf.TargetMethod)
+			pkgs, _, err := ast2.NewPackagesFromPackageNames(pkgDirs, loadConf, filter)
+			if err != nil {
+				return err
 			}
 
-			objects, err := ast2.ExtractObjectsFromFuncDeclRecursive(pkgs.Packages, targetPkg, []types.Object{})
+			objects, initOrder, err := pkgs.ExtractReachableObjects(conf.TargetPackage, conf.TargetMethod, conf.WellKnownInterfaces)
 			if err != nil {
 				return err
 			}
 
-			program := ast2.NewProgram(pkgs, objects)
-			file := program.Bundle(pkg.Syntax)
+			if err := reportUnsupported(pkgs.CheckUnsupported(objects)); err != nil {
+				return err
+			}
+
+			program := ast2.NewProgram(pkgs, objects, initOrder)
+			program.MangleStrategy = mangleStrategy
+			if err := program.InlineEmbeddedFiles(fs, conf.EmbedSizeWarning); err != nil {
+				return err
+			}
+			file, err := program.Bundle()
+			if err != nil {
+				return err
+			}
 
 			buf := new(bytes.Buffer)
 			if err := format.Node(buf, token.NewFileSet(), file); err != nil {
@@ -86,6 +101,9 @@ func NewRootCmd(fs afero.Fs) (*cobra.Command, error) {
 			if err != nil {
 				return err
 			}
+			if constraint := loadConf.BuildConstraint(); constraint != "" {
+				newSrc = append([]byte("//go:build "+constraint+"\n\n"), newSrc...)
+			}
 
 			if _, err := io.WriteString(cmd.OutOrStdout(), string(newSrc)); err != nil {
 				return err
@@ -105,6 +123,27 @@ func NewRootCmd(fs afero.Fs) (*cobra.Command, error) {
 	return cmd, nil
 }
 
+// reportUnsupported logs constructs that cannot be bundled safely.
+// It returns an error if any of them cannot be bundled at all.
+func reportUnsupported(diags []*ast2.Diagnostic) error {
+	errCount := 0
+	for _, d := range diags {
+		if d.Severity == ast2.SeverityError {
+			errCount++
+			log.Printf("error: %s", d)
+			continue
+		}
+		log.Printf("warn: %s", d)
+	}
+	if errCount == 1 {
+		return errors.New("found 1 construct that cannot be bundled")
+	}
+	if errCount > 1 {
+		return fmt.Errorf("found %d constructs that cannot be bundled", errCount)
+	}
+	return nil
+}
+
 func formatSrc(bytes []byte) ([]byte, error) {
 	options := &imports.Options{
 		TabWidth:  8,
@@ -144,6 +183,81 @@ func registerFlags(cmd *cobra.Command) error {
 			},
 			Value: "main.main",
 		},
+		&option.StringSliceFlag{
+			BaseFlag: &option.BaseFlag{
+				Name:  "well-known-interfaces",
+				Usage: "Interfaces whose methods are kept when a value is converted to an interface type",
+			},
+			Value: ast2.DefaultWellKnownInterfaces,
+		},
+		&option.StringSliceFlag{
+			BaseFlag: &option.BaseFlag{
+				Name:  "include",
+				Usage: "Import path patterns of packages to bundle (default: all non-standard packages)",
+			},
+		},
+		&option.StringSliceFlag{
+			BaseFlag: &option.BaseFlag{
+				Name:  "exclude",
+				Usage: "Import path patterns of packages to leave as imports",
+			},
+		},
+		&option.StringSliceFlag{
+			BaseFlag: &option.BaseFlag{
+				Name:  "keep-imports",
+				Usage: "Import path patterns of packages provided by the judge, which are treated like standard packages",
+			},
+		},
+		&option.StringFlag{
+			BaseFlag: &option.BaseFlag{
+				Name:  "dir",
+				Usage: "Directory in which packages are loaded (go.mod or go.work is looked up from here)",
+			},
+		},
+		&option.StringFlag{
+			BaseFlag: &option.BaseFlag{
+				Name:  "mod",
+				Usage: "Module download mode passed to go command as -mod (mod, readonly or vendor)",
+			},
+		},
+		&option.StringFlag{
+			BaseFlag: &option.BaseFlag{
+				Name:  "workfile",
+				Usage: "Path of go.work passed to go command as GOWORK (off disables workspace mode)",
+			},
+		},
+		&option.StringSliceFlag{
+			BaseFlag: &option.BaseFlag{
+				Name:  "tags",
+				Usage: "Build tags used to select files",
+			},
+		},
+		&option.StringFlag{
+			BaseFlag: &option.BaseFlag{
+				Name:  "goos",
+				Usage: "Target operating system used to select files",
+			},
+		},
+		&option.StringFlag{
+			BaseFlag: &option.BaseFlag{
+				Name:  "goarch",
+				Usage: "Target architecture used to select files",
+			},
+		},
+		&option.IntFlag{
+			BaseFlag: &option.BaseFlag{
+				Name:  "embed-size-warning",
+				Usage: "Warn when a file inlined from go:embed is larger than this size in bytes (0 disables the warning)",
+			},
+			Value: ast2.DefaultEmbedSizeWarningThreshold,
+		},
+		&option.StringFlag{
+			BaseFlag: &option.BaseFlag{
+				Name:  "mangle",
+				Usage: "How names declared in library packages are renamed (prefix, conflict or hash)",
+			},
+			Value: string(ast2.ManglePrefix),
+		},
 	}
 	return option.RegisterFlags(cmd, flags)
 }
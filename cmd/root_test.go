@@ -3,6 +3,7 @@ package cmd_test
 import (
 	"bytes"
 	"fmt"
+	"go/build"
 	"io/ioutil"
 	"path/filepath"
 	"strings"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/sergi/go-diff/diffmatchpatch"
 
+	ast2 "github.com/mpppk/gollup/ast"
 	"github.com/mpppk/gollup/cmd"
 
 	"github.com/spf13/afero"
@@ -22,6 +24,7 @@ func TestRoot(t *testing.T) {
 		name         string
 		command      string
 		wantFilePath string
+		fs           afero.Fs
 	}{
 		{
 			name: "single_pkg",
@@ -151,27 +154,276 @@ func TestRoot(t *testing.T) {
 			),
 			wantFilePath: filepath.Join(testDir, "pkgvar", "want", "want.go.test"),
 		},
-		// duplicated name struct is not supported yet
-		//{
-		//	command: fmt.Sprintf("%s %s",
-		//		filepath.Join(testDir, "dup_struct"),
-		//		filepath.Join(testDir, "dup_struct", "lib"),
-		//	),
-		//	wantFilePath: filepath.Join(testDir, "dup_struct", "want", "want.go.test"),
-		//},
-		// duplicated name struct is not supported yet
-		//{
-		//	command: fmt.Sprintf("%s %s",
-		//		filepath.Join(testDir, "nested_struct"),
-		//		filepath.Join(testDir, "nested_struct", "lib"),
-		//	),
-		//	wantFilePath: filepath.Join(testDir, "nested_struct", "want", "want.go.test"),
-		//},
+		{
+			name: "func_value",
+			command: fmt.Sprintf("%s %s",
+				filepath.Join(testDir, "func_value"),
+				filepath.Join(testDir, "func_value", "lib"),
+			),
+			wantFilePath: filepath.Join(testDir, "func_value", "want", "want.go.test"),
+		},
+		{
+			name: "interface",
+			command: fmt.Sprintf("%s %s",
+				filepath.Join(testDir, "interface"),
+				filepath.Join(testDir, "interface", "lib"),
+			),
+			wantFilePath: filepath.Join(testDir, "interface", "want", "want.go.test"),
+		},
+		{
+			name: "std_interface",
+			command: fmt.Sprintf("%s %s",
+				filepath.Join(testDir, "std_interface"),
+				filepath.Join(testDir, "std_interface", "lib"),
+			),
+			wantFilePath: filepath.Join(testDir, "std_interface", "want", "want.go.test"),
+		},
+		{
+			name: "generics",
+			command: fmt.Sprintf("%s %s",
+				filepath.Join(testDir, "generics"),
+				filepath.Join(testDir, "generics", "lib"),
+			),
+			wantFilePath: filepath.Join(testDir, "generics", "want", "want.go.test"),
+		},
+		{
+			name: "type_closure",
+			command: fmt.Sprintf("%s %s",
+				filepath.Join(testDir, "type_closure"),
+				filepath.Join(testDir, "type_closure", "lib"),
+			),
+			wantFilePath: filepath.Join(testDir, "type_closure", "want", "want.go.test"),
+		},
+		{
+			name: "dup_struct",
+			command: fmt.Sprintf("%s %s",
+				filepath.Join(testDir, "dup_struct"),
+				filepath.Join(testDir, "dup_struct", "lib"),
+			),
+			wantFilePath: filepath.Join(testDir, "dup_struct", "want", "want.go.test"),
+		},
+		{
+			name: "nested_struct",
+			command: fmt.Sprintf("%s %s %s",
+				filepath.Join(testDir, "nested_struct"),
+				filepath.Join(testDir, "nested_struct", "lib"),
+				filepath.Join(testDir, "nested_struct", "base"),
+			),
+			wantFilePath: filepath.Join(testDir, "nested_struct", "want", "want.go.test"),
+		},
+		{
+			name: "pkg_init",
+			command: fmt.Sprintf("%s %s",
+				filepath.Join(testDir, "pkg_init"),
+				filepath.Join(testDir, "pkg_init", "lib"),
+			),
+			wantFilePath: filepath.Join(testDir, "pkg_init", "want", "want.go.test"),
+		},
+		{
+			name: "const_group",
+			command: fmt.Sprintf("%s %s",
+				filepath.Join(testDir, "const_group"),
+				filepath.Join(testDir, "const_group", "lib"),
+			),
+			wantFilePath: filepath.Join(testDir, "const_group", "want", "want.go.test"),
+		},
+		{
+			name: "pkg_var_rename",
+			command: fmt.Sprintf("%s %s",
+				filepath.Join(testDir, "pkg_var_rename"),
+				filepath.Join(testDir, "pkg_var_rename", "lib"),
+			),
+			wantFilePath: filepath.Join(testDir, "pkg_var_rename", "want", "want.go.test"),
+		},
+		{
+			name: "init_order",
+			command: fmt.Sprintf("%s %s %s",
+				filepath.Join(testDir, "init_order"),
+				filepath.Join(testDir, "init_order", "lib"),
+				filepath.Join(testDir, "init_order", "base"),
+			),
+			wantFilePath: filepath.Join(testDir, "init_order", "want", "want.go.test"),
+		},
+		{
+			name: "same_name",
+			command: fmt.Sprintf("%s %s",
+				filepath.Join(testDir, "same_name"),
+				filepath.Join(testDir, "same_name", "lib"),
+			),
+			wantFilePath: filepath.Join(testDir, "same_name", "want", "want.go.test"),
+		},
+		{
+			name: "type_alias",
+			command: fmt.Sprintf("%s %s",
+				filepath.Join(testDir, "type_alias"),
+				filepath.Join(testDir, "type_alias", "lib"),
+			),
+			wantFilePath: filepath.Join(testDir, "type_alias", "want", "want.go.test"),
+		},
+		{
+			name: "import_names",
+			command: fmt.Sprintf("%s %s %s %s",
+				filepath.Join(testDir, "import_names"),
+				filepath.Join(testDir, "import_names", "lib"),
+				filepath.Join(testDir, "import_names", "dot"),
+				filepath.Join(testDir, "import_names", "side"),
+			),
+			wantFilePath: filepath.Join(testDir, "import_names", "want", "want.go.test"),
+		},
+		{
+			// library packages are discovered from imports
+			name: "nested_struct without library packages",
+			command: fmt.Sprintf("%s",
+				filepath.Join(testDir, "nested_struct"),
+			),
+			wantFilePath: filepath.Join(testDir, "nested_struct", "want", "want.go.test"),
+		},
+		{
+			name: "import_names with excluded package",
+			command: fmt.Sprintf("--exclude %s %s",
+				"github.com/mpppk/gollup/testdata/import_names/lib",
+				filepath.Join(testDir, "import_names"),
+			),
+			wantFilePath: filepath.Join(testDir, "import_names", "want", "exclude.go.test"),
+		},
+		{
+			// third-party modules are bundled from the module cache
+			name: "third_party",
+			command: fmt.Sprintf("%s",
+				filepath.Join(testDir, "third_party"),
+			),
+			wantFilePath: filepath.Join(testDir, "third_party", "want", "want.go.test"),
+		},
+		{
+			name: "keep_import",
+			command: fmt.Sprintf("--keep-imports %s %s",
+				"github.com/blang/...",
+				filepath.Join(testDir, "keep_import"),
+			),
+			wantFilePath: filepath.Join(testDir, "keep_import", "want", "want.go.test"),
+		},
+		{
+			// library module is provided by go.work
+			name: "workspace",
+			command: fmt.Sprintf("--dir %s --mod readonly .",
+				filepath.Join(testDir, "modules", "workspace", "app"),
+			),
+			wantFilePath: filepath.Join(testDir, "modules", "workspace", "want.go.test"),
+		},
+		{
+			// library module is provided by replace directive
+			name: "replace",
+			command: fmt.Sprintf("--dir %s .",
+				filepath.Join(testDir, "modules", "replace", "app"),
+			),
+			wantFilePath: filepath.Join(testDir, "modules", "replace", "want.go.test"),
+		},
+		{
+			name: "vendor",
+			command: fmt.Sprintf("--dir %s --mod vendor .",
+				filepath.Join(testDir, "modules", "vendor", "app"),
+			),
+			wantFilePath: filepath.Join(testDir, "modules", "vendor", "want.go.test"),
+		},
+		{
+			name: "build_tags with debug tag on windows",
+			command: fmt.Sprintf("--tags debug --goos windows --goarch amd64 %s",
+				filepath.Join(testDir, "build_tags"),
+			),
+			wantFilePath: filepath.Join(testDir, "build_tags", "want", "debug_windows.go.test"),
+		},
+		{
+			name: "build_tags on linux",
+			command: fmt.Sprintf("--goos linux %s",
+				filepath.Join(testDir, "build_tags"),
+			),
+			wantFilePath: filepath.Join(testDir, "build_tags", "want", "release_linux.go.test"),
+		},
+		{
+			name: "embed",
+			command: fmt.Sprintf("%s %s",
+				filepath.Join(testDir, "embed"),
+				filepath.Join(testDir, "embed", "lib"),
+			),
+			wantFilePath: filepath.Join(testDir, "embed", "want", "want.go.test"),
+			fs:           afero.NewReadOnlyFs(afero.NewOsFs()),
+		},
+		{
+			name: "qualified_refs",
+			command: fmt.Sprintf("%s %s",
+				filepath.Join(testDir, "qualified_refs"),
+				filepath.Join(testDir, "qualified_refs", "lib"),
+			),
+			wantFilePath: filepath.Join(testDir, "qualified_refs", "want", "want.go.test"),
+		},
+		{
+			name: "mangle with prefix",
+			command: fmt.Sprintf("--mangle prefix %s %s %s",
+				filepath.Join(testDir, "mangle"),
+				filepath.Join(testDir, "mangle", "lib", "graph", "util"),
+				filepath.Join(testDir, "mangle", "lib", "math", "util"),
+			),
+			wantFilePath: filepath.Join(testDir, "mangle", "want", "prefix.go.test"),
+		},
+		{
+			name: "mangle with conflict",
+			command: fmt.Sprintf("--mangle conflict %s %s %s",
+				filepath.Join(testDir, "mangle"),
+				filepath.Join(testDir, "mangle", "lib", "graph", "util"),
+				filepath.Join(testDir, "mangle", "lib", "math", "util"),
+			),
+			wantFilePath: filepath.Join(testDir, "mangle", "want", "conflict.go.test"),
+		},
+		{
+			name: "mangle with hash",
+			command: fmt.Sprintf("--mangle hash %s %s %s",
+				filepath.Join(testDir, "mangle"),
+				filepath.Join(testDir, "mangle", "lib", "graph", "util"),
+				filepath.Join(testDir, "mangle", "lib", "math", "util"),
+			),
+			wantFilePath: filepath.Join(testDir, "mangle", "want", "hash.go.test"),
+		},
+		{
+			// import paths which are the same after replacing invalid characters with _
+			name: "mangle_collision",
+			command: fmt.Sprintf("%s",
+				filepath.Join(testDir, "mangle_collision"),
+			),
+			wantFilePath: filepath.Join(testDir, "mangle_collision", "want", "want.go.test"),
+		},
+		{
+			name: "shadowing",
+			command: fmt.Sprintf("%s %s",
+				filepath.Join(testDir, "shadowing"),
+				filepath.Join(testDir, "shadowing", "lib"),
+			),
+			wantFilePath: filepath.Join(testDir, "shadowing", "want", "want.go.test"),
+		},
+		{
+			name: "imports",
+			command: fmt.Sprintf("%s %s",
+				filepath.Join(testDir, "imports"),
+				filepath.Join(testDir, "imports", "lib"),
+			),
+			wantFilePath: filepath.Join(testDir, "imports", "want", "want.go.test"),
+		},
+		{
+			name: "reflect_unsafe",
+			command: fmt.Sprintf("%s %s",
+				filepath.Join(testDir, "reflect_unsafe"),
+				filepath.Join(testDir, "reflect_unsafe", "lib"),
+			),
+			wantFilePath: filepath.Join(testDir, "reflect_unsafe", "want", "want.go.test"),
+		},
 	}
 
 	for _, c := range cases {
 		buf := new(bytes.Buffer)
-		rootCmd, err := cmd.NewRootCmd(afero.NewMemMapFs())
+		fs := c.fs
+		if fs == nil {
+			fs = afero.NewMemMapFs()
+		}
+		rootCmd, err := cmd.NewRootCmd(fs)
 		if err != nil {
 			t.Errorf("failed to create rootCmd: %s", err)
 		}
@@ -209,6 +461,132 @@ func TestRoot(t *testing.T) {
 	}
 }
 
+func TestRootUnsupported(t *testing.T) {
+	cases := []struct {
+		name      string
+		pkgDir    string
+		needsCgo  bool
+		wantErr   string
+		wantDiags []string
+	}{
+		{
+			name:    "assembly and go:linkname",
+			pkgDir:  filepath.Join(testDir, "unsupported"),
+			wantErr: "found 2 constructs that cannot be bundled",
+			wantDiags: []string{
+				"unsupported/lib/asm.go:4: error: function Add has no body. functions implemented in assembly are not supported",
+				"unsupported/lib/linkname.go:5: error: go:linkname is not supported: nanotime",
+			},
+		},
+		{
+			name:     "cgo",
+			pkgDir:   filepath.Join(testDir, "unsupported_cgo"),
+			needsCgo: true,
+			wantErr:  "found 1 construct that cannot be bundled",
+			wantDiags: []string{
+				`unsupported_cgo/lib/cgo.go:6: error: cgo is not supported: package github.com/mpppk/gollup/testdata/unsupported_cgo/lib imports "C"`,
+			},
+		},
+		{
+			name:    "embed.FS",
+			pkgDir:  filepath.Join(testDir, "embed_fs"),
+			wantErr: "found 1 construct that cannot be bundled",
+			wantDiags: []string{
+				"embed_fs/lib/lib.go:6: error: go:embed is only supported for string and []byte variables: files has type embed.FS",
+			},
+		},
+		{
+			name:   "reflect and unsafe",
+			pkgDir: filepath.Join(testDir, "reflect_unsafe"),
+			wantDiags: []string{
+				"reflect_unsafe/lib/lib.go:15: warning: reflect.Type.Name depends on names or method sets, which are changed by bundling",
+				"reflect_unsafe/lib/lib.go:20: warning: unsafe.Pointer is used. the bundle may behave differently",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if c.needsCgo && !build.Default.CgoEnabled {
+				t.Skip("cgo is not available")
+			}
+
+			diags := checkUnsupported(t, c.pkgDir)
+			if len(diags) != len(c.wantDiags) {
+				t.Fatalf("unexpected diagnostics. want: %q, got: %q", c.wantDiags, diags)
+			}
+			for i, want := range c.wantDiags {
+				if diags[i] != want {
+					t.Errorf("unexpected diagnostic. want: %q, got: %q", want, diags[i])
+				}
+			}
+
+			if c.wantErr == "" {
+				return
+			}
+			buf := new(bytes.Buffer)
+			rootCmd, err := cmd.NewRootCmd(afero.NewMemMapFs())
+			if err != nil {
+				t.Fatalf("failed to create rootCmd: %s", err)
+			}
+			rootCmd.SetOut(buf)
+			rootCmd.SetErr(buf)
+			rootCmd.SetArgs([]string{c.pkgDir})
+			if err := rootCmd.Execute(); err == nil || err.Error() != c.wantErr {
+				t.Errorf("unexpected error. want: %q, got: %v", c.wantErr, err)
+			}
+			if buf.Len() != 0 {
+				t.Errorf("nothing should be emitted when unsupported constructs are reachable: %s", buf.String())
+			}
+		})
+	}
+}
+
+// checkUnsupported runs CheckUnsupported on the objects reachable from main.main of pkgDir
+// and returns the diagnostics formatted as "path from testdata:line: severity: message"
+func checkUnsupported(t *testing.T, pkgDir string) (diags []string) {
+	t.Helper()
+	pkgs, _, err := ast2.NewPackagesFromPackageNames([]string{pkgDir}, nil, &ast2.PackageFilter{})
+	if err != nil {
+		t.Fatalf("failed to load packages: %s", err)
+	}
+	objects, _, err := pkgs.ExtractReachableObjects("main", "main", ast2.DefaultWellKnownInterfaces)
+	if err != nil {
+		t.Fatalf("failed to extract objects: %s", err)
+	}
+
+	root, err := filepath.Abs(testDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range pkgs.CheckUnsupported(objects) {
+		rel, err := filepath.Rel(root, d.Pos.Filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		diags = append(diags, fmt.Sprintf("%s:%d: %s: %s", filepath.ToSlash(rel), d.Pos.Line, d.Severity, d.Message))
+	}
+	return
+}
+
+func TestRootMissingModule(t *testing.T) {
+	buf := new(bytes.Buffer)
+	rootCmd, err := cmd.NewRootCmd(afero.NewMemMapFs())
+	if err != nil {
+		t.Fatalf("failed to create rootCmd: %s", err)
+	}
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"--dir", filepath.Join(testDir, "modules", "missing", "app"), "."})
+	err = rootCmd.Execute()
+	if err == nil {
+		t.Fatalf("rootCmd should fail when a required module is not in the module cache")
+	}
+	if !strings.Contains(err.Error(), "example.com/missing") || !strings.Contains(err.Error(), "go mod download") {
+		t.Errorf("error should tell to download the missing module: %s", err)
+	}
+}
+
 func removeCarriageReturn(s string) string {
 	return strings.Replace(s, "\r", "", -1)
 }
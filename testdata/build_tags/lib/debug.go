@@ -0,0 +1,9 @@
+//go:build debug
+
+package lib
+
+const Mode = "debug"
+
+func Log(s string) string {
+	return "[" + Mode + "] " + s
+}
@@ -0,0 +1,5 @@
+package lib
+
+func NewLine() string {
+	return "\r\n"
+}
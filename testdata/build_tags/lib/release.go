@@ -0,0 +1,9 @@
+//go:build !debug
+
+package lib
+
+const Mode = "release"
+
+func Log(s string) string {
+	return s
+}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mpppk/gollup/testdata/build_tags/lib"
+)
+
+func main() {
+	fmt.Print(lib.Log("hello"), lib.NewLine())
+}
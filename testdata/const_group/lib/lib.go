@@ -0,0 +1,37 @@
+package lib
+
+import "fmt"
+
+const Inf int64 = 1 << 60
+
+type Kind int
+
+const (
+	KindA Kind = iota
+	KindB
+	KindC
+	KindD
+)
+
+func (k Kind) String() string {
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+const (
+	_  = iota
+	KB = 1 << (10 * iota)
+	MB
+	GB
+)
+
+const (
+	X, Y = 1, 2
+	Z, W
+)
+
+const Base = 10
+
+const (
+	Unused  = Base * 2
+	Unused2 = Unused + 1
+)
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mpppk/gollup/testdata/const_group/lib"
+)
+
+func main() {
+	var x interface{} = lib.Inf
+	_, ok := x.(int64)
+	fmt.Println(lib.Inf, ok)
+	fmt.Println(lib.KindC, lib.KindA)
+	fmt.Println(lib.MB, lib.W)
+}
@@ -5,3 +5,25 @@ type S struct{}
 func (S *S) F() int {
 	return 1
 }
+
+type ID int
+
+type Node struct {
+	ID   ID
+	Next *Node
+}
+
+func (n *Node) Len() int {
+	if n.Next == nil {
+		return 1
+	}
+	return 1 + n.Next.Len()
+}
+
+type Box[T any] struct {
+	V T
+}
+
+func NewNode(id int, next *Node) *Node {
+	return &Node{ID: ID(id), Next: next}
+}
@@ -3,7 +3,7 @@ package main
 import (
 	"fmt"
 
-	"github.com/mpppk/gollup/testdata/struct/lib"
+	"github.com/mpppk/gollup/testdata/dup_struct/lib"
 )
 
 type S struct{}
@@ -12,8 +12,35 @@ func (S *S) F() int {
 	return 1
 }
 
+type Node struct {
+	Name string
+}
+
+type Tree struct {
+	Root  *lib.Node
+	Names []Node
+}
+
+func describe(x interface{}) string {
+	switch v := x.(type) {
+	case lib.Node:
+		return fmt.Sprint("lib.Node ", v.ID)
+	case Node:
+		return "Node " + v.Name
+	}
+	return "unknown"
+}
+
 func main() {
 	s1 := S{}
 	s2 := lib.S{}
 	fmt.Println(s1.F(), s2.F())
+
+	t := Tree{Root: lib.NewNode(1, lib.NewNode(2, nil)), Names: []Node{{Name: "a"}}}
+	var x interface{} = *t.Root
+	if n, ok := x.(lib.Node); ok {
+		fmt.Println(n.Len(), lib.ID(3), describe(n), describe(t.Names[0]))
+	}
+	b := lib.Box[lib.Node]{V: lib.Node{ID: 4}}
+	fmt.Println(b.V.ID)
 }
@@ -0,0 +1,29 @@
+package lib
+
+import (
+	"embed"
+	"strconv"
+	"strings"
+)
+
+var (
+	//go:embed data/table.txt
+	table string
+
+	//go:embed "data/binary.bin"
+	binary []byte
+)
+
+//go:embed data
+var unused embed.FS
+
+// Lookup returns the name of n in the table
+func Lookup(key string) string {
+	n, _ := strconv.Atoi(key)
+	return strings.Split(table, "\n")[n]
+}
+
+// Binary returns the embedded binary data
+func Binary() []byte {
+	return binary
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"github.com/mpppk/gollup/testdata/embed/lib"
+)
+
+//go:embed input.txt
+var input string
+
+func main() {
+	for _, line := range strings.Fields(input) {
+		fmt.Println(line, lib.Lookup(line))
+	}
+	fmt.Println(len(lib.Binary()))
+}
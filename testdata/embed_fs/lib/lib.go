@@ -0,0 +1,12 @@
+package lib
+
+import "embed"
+
+//go:embed data
+var files embed.FS
+
+// Read returns the content of the embedded file
+func Read(name string) string {
+	b, _ := files.ReadFile("data/" + name)
+	return string(b)
+}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mpppk/gollup/testdata/embed_fs/lib"
+)
+
+func main() {
+	fmt.Print(lib.Read("hello.txt"))
+}
@@ -0,0 +1,37 @@
+package lib
+
+import "fmt"
+
+func Less(a, b int) bool {
+	return a < b
+}
+
+func Visit(v int) int {
+	return v * 2
+}
+
+func Flush() {
+	fmt.Println("flush")
+}
+
+func apply(f func(int) int, v int) int {
+	return f(v)
+}
+
+func Double(v int) int {
+	return apply(Visit, v)
+}
+
+type Stack struct {
+	items []int
+}
+
+func (s *Stack) Push(v int) {
+	s.items = append(s.items, v)
+}
+
+func (s *Stack) Len() int {
+	return len(s.items)
+}
+
+func (s *Stack) Unused() {}
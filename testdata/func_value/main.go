@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/mpppk/gollup/testdata/func_value/lib"
+)
+
+func main() {
+	xs := []int{3, 1, 2}
+	sort.Slice(xs, func(i, j int) bool { return lib.Less(xs[i], xs[j]) })
+	dfs := lib.Visit
+	defer func() { lib.Flush() }()
+
+	st := &lib.Stack{}
+	pushes := []func(int){st.Push}
+	for _, push := range pushes {
+		push(dfs(1))
+	}
+	push := (*lib.Stack).Push
+	push(st, lib.Double(2))
+	length := st.Len
+	fmt.Println(xs, length())
+}
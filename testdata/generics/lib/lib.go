@@ -0,0 +1,102 @@
+package lib
+
+type Ordered interface {
+	~int | ~int64 | ~float64 | ~string
+}
+
+func Max[T Ordered](a, b T) T {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+type SegTree[T any] struct {
+	n    int
+	data []T
+	op   func(a, b T) T
+	e    T
+}
+
+func NewSegTree[T any](n int, op func(a, b T) T, e T) *SegTree[T] {
+	size := 1
+	for size < n {
+		size *= 2
+	}
+	data := make([]T, 2*size)
+	for i := range data {
+		data[i] = e
+	}
+	return &SegTree[T]{n: size, data: data, op: op, e: e}
+}
+
+func (s *SegTree[T]) Set(i int, v T) {
+	i += s.n
+	s.data[i] = v
+	for i > 1 {
+		i /= 2
+		s.data[i] = s.op(s.data[2*i], s.data[2*i+1])
+	}
+}
+
+func (s *SegTree[T]) Query(l, r int) T {
+	resL, resR := s.e, s.e
+	for l, r = l+s.n, r+s.n; l < r; l, r = l/2, r/2 {
+		if l&1 == 1 {
+			resL = s.op(resL, s.data[l])
+			l++
+		}
+		if r&1 == 1 {
+			r--
+			resR = s.op(s.data[r], resR)
+		}
+	}
+	return s.op(resL, resR)
+}
+
+func (s *SegTree[T]) Unused() {}
+
+type Pair[K comparable, V any] struct {
+	Key K
+	Val V
+}
+
+func MakePair[K comparable, V any](k K, v V) Pair[K, V] {
+	return Pair[K, V]{Key: k, Val: v}
+}
+
+func (p Pair[K, V]) Values() []V {
+	return []V{p.Val}
+}
+
+func Map[T, U any](xs []T, f func(T) U) []U {
+	ys := make([]U, 0, len(xs))
+	for _, x := range xs {
+		ys = append(ys, f(x))
+	}
+	return ys
+}
+
+func MaxAll[T Ordered](xs ...T) T {
+	m := xs[0]
+	for _, x := range xs {
+		m = Max[T](m, x)
+	}
+	return m
+}
+
+type Container[T any] interface {
+	Get() T
+}
+
+type Box[T any] struct {
+	v T
+}
+
+func New[T any](v T) Container[T] {
+	return &Box[T]{v: v}
+}
+
+func (b *Box[T]) Get() T {
+	return b.v
+}
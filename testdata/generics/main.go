@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mpppk/gollup/testdata/generics/lib"
+)
+
+func main() {
+	fmt.Println(lib.Max(1, 2), lib.Max[float64](1.5, 0.5), lib.MaxAll("a", "c", "b"))
+
+	st := lib.NewSegTree[int](8, lib.Max[int], 0)
+	st.Set(3, 5)
+	st.Set(6, 2)
+	fmt.Println(st.Query(0, 8), st.Query(4, 8))
+
+	var p lib.Pair[string, int] = lib.MakePair("a", 1)
+	fmt.Println(p.Key, p.Values())
+	fmt.Println(lib.Map([]int{1, 2}, func(v int) string { return fmt.Sprint(v * 2) }))
+	fmt.Println(lib.New(3).Get())
+}
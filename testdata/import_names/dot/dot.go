@@ -0,0 +1,16 @@
+package dot
+
+import l "github.com/mpppk/gollup/testdata/import_names/lib"
+
+const Step = 2
+
+var Total int
+
+type Pair struct {
+	A, B int
+}
+
+func Double(c *l.Counter) {
+	c.Add(c.N)
+	Total += c.N
+}
@@ -0,0 +1,15 @@
+package lib
+
+const Base = 100
+
+type Counter struct {
+	N int
+}
+
+func (c *Counter) Add(n int) {
+	c.N += n
+}
+
+func New() *Counter {
+	return &Counter{N: Base}
+}
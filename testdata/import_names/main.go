@@ -0,0 +1,17 @@
+package main
+
+import (
+	"fmt"
+
+	. "github.com/mpppk/gollup/testdata/import_names/dot"
+	l "github.com/mpppk/gollup/testdata/import_names/lib"
+	_ "github.com/mpppk/gollup/testdata/import_names/side"
+)
+
+func main() {
+	c := l.New()
+	c.Add(Step)
+	Double(c)
+	p := Pair{A: c.N, B: l.Base}
+	fmt.Println(p, Total)
+}
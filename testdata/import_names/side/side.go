@@ -0,0 +1,7 @@
+package side
+
+import "fmt"
+
+func init() {
+	fmt.Println("side effect")
+}
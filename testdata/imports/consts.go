@@ -0,0 +1,3 @@
+package main
+
+const seed = 42
@@ -0,0 +1,12 @@
+package lib
+
+import (
+	. "math"
+	"strings"
+)
+
+// Dot uses a dot-imported package
+func Dot() string {
+	math := Sqrt(16)
+	return strings.TrimSpace(" dot ") + string(rune('0'+int(math)))
+}
@@ -0,0 +1,19 @@
+package lib
+
+import (
+	"crypto/rand"
+	_ "image/png"
+	str "strings"
+)
+
+// Banner returns a banner of n stars
+func Banner(n int) string {
+	return str.Repeat("*", n)
+}
+
+// RandomSize reads random bytes and returns the number of bytes read
+func RandomSize() int {
+	crand := make([]byte, 4)
+	n, _ := rand.Read(crand)
+	return n
+}
@@ -0,0 +1,13 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/mpppk/gollup/testdata/imports/lib"
+)
+
+func main() {
+	r := rand.New(rand.NewSource(seed))
+	fmt.Println(r.Intn(100), shout(lib.Banner(3)), lib.RandomSize(), lib.Dot())
+}
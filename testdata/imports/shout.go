@@ -0,0 +1,13 @@
+package main
+
+import (
+	crand "crypto/rand"
+	f "fmt"
+	. "strings"
+)
+
+func shout(s string) string {
+	b := make([]byte, 1)
+	_, err := crand.Read(b)
+	return f.Sprint(ToUpper(s), " ", err == nil)
+}
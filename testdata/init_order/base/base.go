@@ -0,0 +1,27 @@
+package base
+
+import (
+	"bytes"
+	"fmt"
+)
+
+var Log []string
+
+func init() {
+	Log = append(Log, "base")
+}
+
+func Trace(s string) {
+	Log = append(Log, s)
+	fmt.Println("trace:", s)
+}
+
+var Table []int
+
+func init() {
+	Table = []int{1, 2, 3}
+}
+
+func NewBuffer(s string) *bytes.Buffer {
+	return bytes.NewBufferString(s)
+}
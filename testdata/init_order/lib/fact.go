@@ -0,0 +1,19 @@
+package lib
+
+import "github.com/mpppk/gollup/testdata/init_order/base"
+
+const MaxN = 10
+
+var fact [MaxN]int
+
+func init() {
+	fact[0] = 1
+	for i := 1; i < MaxN; i++ {
+		fact[i] = fact[i-1] * i
+	}
+	base.Trace("fact")
+}
+
+func Fact(n int) int {
+	return fact[n]
+}
@@ -0,0 +1,17 @@
+package lib
+
+import "github.com/mpppk/gollup/testdata/init_order/base"
+
+var seed = newSeed()
+
+func newSeed() int {
+	return 42
+}
+
+func init() {
+	base.Trace("lib second init")
+}
+
+func Seed() int {
+	return seed
+}
@@ -0,0 +1,17 @@
+package lib
+
+import "github.com/mpppk/gollup/testdata/init_order/base"
+
+var First = base.Table[0]
+
+var buf = base.NewBuffer("size")
+
+var quo, rem = divmod(base.Table[2]+First, 3)
+
+func divmod(a, b int) (int, int) {
+	return a / b, a % b
+}
+
+func Table() (int, int, int, string) {
+	return First, quo, rem, buf.String()
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mpppk/gollup/testdata/init_order/base"
+	"github.com/mpppk/gollup/testdata/init_order/lib"
+)
+
+var last = base.Table[len(base.Table)-1]
+
+func init() {
+	base.Trace("main")
+}
+
+func main() {
+	fmt.Println(lib.Fact(5), lib.Seed(), base.Log)
+	fmt.Println(lib.Table())
+	fmt.Println(last)
+}
@@ -0,0 +1,45 @@
+package lib
+
+type Solver interface {
+	Solve() int
+}
+
+type DP struct {
+	memo []int
+}
+
+func NewDP() *DP {
+	return &DP{memo: make([]int, 10)}
+}
+
+func (dp *DP) Solve() int {
+	return dp.fib(len(dp.memo) - 1)
+}
+
+func (dp *DP) fib(n int) int {
+	if n < 2 {
+		return n
+	}
+	if dp.memo[n] == 0 {
+		dp.memo[n] = dp.fib(n-1) + dp.fib(n-2)
+	}
+	return dp.memo[n]
+}
+
+type Greedy struct{}
+
+func (g Greedy) Solve() int {
+	return 0
+}
+
+type Wrapper struct {
+	Inner Solver
+}
+
+func NewWrapper(s Solver) Solver {
+	return &Wrapper{Inner: s}
+}
+
+func (w *Wrapper) Solve() int {
+	return w.Inner.Solve() + 1
+}
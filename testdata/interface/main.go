@@ -0,0 +1,13 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mpppk/gollup/testdata/interface/lib"
+)
+
+func main() {
+	var s lib.Solver = lib.NewDP()
+	s = lib.NewWrapper(s)
+	fmt.Println(s.Solve())
+}
@@ -0,0 +1,7 @@
+package lib
+
+import sv "github.com/blang/semver"
+
+func Newer(a, b string) bool {
+	return Parse(a).GT(sv.MustParse(b))
+}
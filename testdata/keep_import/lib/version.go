@@ -0,0 +1,7 @@
+package lib
+
+import "github.com/blang/semver"
+
+func Parse(s string) semver.Version {
+	return semver.MustParse(s)
+}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mpppk/gollup/testdata/keep_import/lib"
+)
+
+func main() {
+	fmt.Println(lib.Newer("1.2.0", "1.1.9"), lib.Parse("2.0.0").Major)
+}
@@ -0,0 +1,12 @@
+package util
+
+// Max is the max number of vertices
+const Max = 100
+
+// Gcd returns the gcd of the degrees
+func Gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
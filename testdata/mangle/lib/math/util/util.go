@@ -0,0 +1,22 @@
+package util
+
+// Gcd returns the greatest common divisor of a and b
+func Gcd(a, b int) int {
+	if b == 0 {
+		return Abs(a)
+	}
+	return Gcd(b, a%b)
+}
+
+// Abs returns the absolute value of a
+func Abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// Square returns a squared
+func Square(a int) int {
+	return a * a
+}
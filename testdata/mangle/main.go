@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+
+	graph "github.com/mpppk/gollup/testdata/mangle/lib/graph/util"
+	"github.com/mpppk/gollup/testdata/mangle/lib/math/util"
+)
+
+var util_Max = 10
+
+func Abs(a float64) float64 {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+func main() {
+	fmt.Println(graph.Gcd(12, 18), util.Gcd(-12, 18), util.Square(3))
+	fmt.Println(graph.Max, util_Max, Abs(-1.5))
+}
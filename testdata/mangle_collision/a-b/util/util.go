@@ -0,0 +1,9 @@
+package util
+
+// Gcd returns the greatest common divisor of a and b
+func Gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,9 @@
+package util
+
+// Gcd returns the greatest common divisor of a and b by recursion
+func Gcd(a, b int) int {
+	if b == 0 {
+		return a
+	}
+	return Gcd(b, a%b)
+}
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"fmt"
+
+	util1 "github.com/mpppk/gollup/testdata/mangle_collision/a-b/util"
+	util2 "github.com/mpppk/gollup/testdata/mangle_collision/a_b/util"
+)
+
+func main() {
+	fmt.Println(util1.Gcd(12, 18), util2.Gcd(12, 18))
+}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"fmt"
+
+	"example.com/missing"
+)
+
+func main() {
+	fmt.Println(missing.Hello())
+}
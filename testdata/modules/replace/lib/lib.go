@@ -0,0 +1,7 @@
+package lib
+
+func Twice(n int) int {
+	return n * 2
+}
+
+func Unused() {}
@@ -0,0 +1,13 @@
+package base
+
+import "fmt"
+
+type Base struct {
+	ID int
+}
+
+func (b *Base) Describe() string {
+	return fmt.Sprint("id=", b.ID)
+}
+
+func (b Base) Unused() {}
@@ -1,7 +1,22 @@
 package lib
 
+import "github.com/mpppk/gollup/testdata/nested_struct/base"
+
+type Counter struct {
+	N int
+}
+
+func (c *Counter) Inc() {
+	c.N++
+}
+
+func (c *Counter) Unused() {}
+
 type S struct {
-	S2 *S2
+	*Counter
+	base.Base
+	S2   *S2
+	Name string
 }
 
 type S2 struct {
@@ -10,10 +25,25 @@ type S2 struct {
 
 func NewS() *S {
 	return &S{
-		S2: &S2{1},
+		Counter: &Counter{},
+		Base:    base.Base{ID: 7},
+		S2:      &S2{1},
 	}
 }
 
 func (S *S) F() int {
-	return S.S2.Num
+	S.Inc()
+	return S.S2.Num + S.N
+}
+
+type Outer struct {
+	S
+}
+
+type Label struct {
+	Text string
+}
+
+type Tagged struct {
+	Label
 }
@@ -3,10 +3,19 @@ package main
 import (
 	"fmt"
 
-	"github.com/mpppk/gollup/testdata/struct/lib"
+	"github.com/mpppk/gollup/testdata/nested_struct/lib"
 )
 
 func main() {
-	s := lib.S{}
-	fmt.Println(s.F())
+	s := lib.NewS()
+	s.Inc()
+	fmt.Println(s.F(), s.Describe(), s.ID, s.Counter.N)
+
+	o := lib.Outer{S: *s}
+	o.Inc()
+	fmt.Println(o.N, o.Base.Describe())
+
+	var t lib.Tagged
+	t.Text = "tagged"
+	fmt.Println(t.Label.Text)
 }
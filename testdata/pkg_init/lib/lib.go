@@ -0,0 +1,29 @@
+package lib
+
+const MaxN = 10
+const Mod = 7
+const Mod2 = Mod * 2
+
+var memo = make([]int, MaxN)
+var table = buildTable()
+var unused = buildUnused()
+
+type Entry struct {
+	V int
+}
+
+func buildTable() []Entry {
+	t := make([]Entry, 3)
+	for i := range t {
+		t[i] = Entry{V: i * Mod2}
+	}
+	return t
+}
+
+func buildUnused() int {
+	return 0
+}
+
+func Get(i int) int {
+	return table[i].V + len(memo)
+}
@@ -0,0 +1,13 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mpppk/gollup/testdata/pkg_init/lib"
+)
+
+var answer = lib.Get(1) + lib.Mod2
+
+func main() {
+	fmt.Println(lib.Get(2), answer)
+}
@@ -0,0 +1,25 @@
+package lib
+
+import "strconv"
+
+var Count, Limit, unusedA = 0, 100, 2
+var Hook func(int) int
+var Name, Err = split("gollup:1")
+var unusedB, unusedC int
+
+func split(s string) (string, error) {
+	_, err := strconv.Atoi(s[len(s)-1:])
+	return s[:len(s)-2], err
+}
+
+func Incr() int {
+	Count++
+	if Hook != nil {
+		return Hook(Count)
+	}
+	return Count
+}
+
+func CountPtr() *int {
+	return &Count
+}
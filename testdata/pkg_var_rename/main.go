@@ -0,0 +1,17 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mpppk/gollup/testdata/pkg_var_rename/lib"
+)
+
+var Count = 10
+
+func main() {
+	lib.Hook = func(n int) int { return n * Count }
+	lib.Incr()
+	*lib.CountPtr() += lib.Limit
+	lib.Count++
+	fmt.Println(Count, lib.Count, lib.Name)
+}
@@ -0,0 +1,38 @@
+package lib
+
+import "strconv"
+
+// ID identifies an item
+type ID int
+
+// Item is an item stored in a Store
+type Item struct {
+	ID   ID
+	Name string
+}
+
+// Store stores items by ID
+type Store map[ID]*Item
+
+// Handler handles an item
+type Handler func(*Item) error
+
+func (i *Item) String() string {
+	return strconv.Itoa(int(i.ID)) + ":" + i.Name
+}
+
+// Pair holds two values
+type Pair[T any] struct {
+	First, Second T
+}
+
+// MakePair returns a Pair
+func MakePair[T any](first, second T) Pair[T] {
+	return Pair[T]{First: first, Second: second}
+}
+
+// Max is the max number of items
+const Max = 3
+
+// Default is the default item
+var Default = Item{ID: 0, Name: "default"}
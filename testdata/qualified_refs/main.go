@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mpppk/gollup/testdata/qualified_refs/lib"
+)
+
+type inventory struct {
+	items   []lib.Item
+	byID    map[lib.ID]*lib.Item
+	handler lib.Handler
+	pair    lib.Pair[lib.ID]
+}
+
+func (inv *inventory) add(item lib.Item) (lib.ID, *lib.Item) {
+	inv.items = append(inv.items, item)
+	p := &inv.items[len(inv.items)-1]
+	inv.byID[item.ID] = p
+	return item.ID, p
+}
+
+func newInventory(handler func(*lib.Item) error) *inventory {
+	return &inventory{
+		items:   make([]lib.Item, 0, lib.Max),
+		byID:    lib.Store{},
+		handler: handler,
+		pair:    lib.MakePair[lib.ID](1, 2),
+	}
+}
+
+func describe(v interface{}) string {
+	switch t := v.(type) {
+	case *lib.Item:
+		return "item " + t.String()
+	case lib.ID:
+		return fmt.Sprint("id ", int(t))
+	}
+	if s, ok := v.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return "unknown"
+}
+
+func main() {
+	inv := newInventory(func(item *lib.Item) error {
+		fmt.Println("handle", item.Name)
+		return nil
+	})
+	id, item := inv.add(lib.Item{ID: 1, Name: "apple"})
+	inv.add(lib.Default)
+	copied := new(lib.Item)
+	*copied = *item
+	var ids []lib.ID
+	for id := range inv.byID {
+		ids = append(ids, id)
+	}
+	fmt.Println(len(ids), describe(id), describe(copied), inv.pair.First+inv.pair.Second)
+	if err := inv.handler(copied); err != nil {
+		panic(err)
+	}
+	conv := lib.ID(3)
+	fmt.Println(describe(conv), describe(lib.Default))
+}
@@ -0,0 +1,31 @@
+package lib
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// Item is an item
+type Item struct {
+	Value int32
+}
+
+// Kind returns the name of the type of v
+func Kind(v interface{}) string {
+	return reflect.TypeOf(v).Name()
+}
+
+// Bits returns the bits of f
+func Bits(f float32) uint32 {
+	return *(*uint32)(unsafe.Pointer(&f))
+}
+
+// Size returns the size of Item
+func Size() uintptr {
+	return unsafe.Sizeof(Item{})
+}
+
+// Text returns v as a string
+func Text(v interface{}) string {
+	return reflect.ValueOf(v).String()
+}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mpppk/gollup/testdata/reflect_unsafe/lib"
+)
+
+func main() {
+	fmt.Println(lib.Kind(lib.Item{}), lib.Bits(1), lib.Size(), lib.Text("item"))
+}
@@ -0,0 +1,12 @@
+package lib
+
+var Max = 10
+
+func helper() int {
+	Max := Max * 2
+	return Max
+}
+
+func Unused() Pair {
+	return Pair{}
+}
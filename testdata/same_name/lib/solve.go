@@ -0,0 +1,13 @@
+package lib
+
+type Pair struct {
+	A, B int
+}
+
+func Solve(n int) int {
+	type Pair struct {
+		X int
+	}
+	p := Pair{X: n}
+	return p.X + helper()
+}
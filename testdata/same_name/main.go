@@ -0,0 +1,17 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mpppk/gollup/testdata/same_name/lib"
+)
+
+const Max = 3
+
+func Solve() int {
+	return lib.Solve(Max)
+}
+
+func main() {
+	fmt.Println(Solve())
+}
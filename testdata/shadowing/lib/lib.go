@@ -0,0 +1,26 @@
+package lib
+
+// Max is the max value
+const Max = 10
+
+// Item is an item
+type Item struct {
+	Value int
+}
+
+// F1 returns a doubled value limited by Max
+func F1(v int) int {
+	lib_Max := v * 2
+	if lib_Max > Max {
+		return Max
+	}
+	return lib_Max
+}
+
+// Sum returns the sum of item values
+func Sum(items ...Item) (lib_Item int) {
+	for _, item := range items {
+		lib_Item += F1(item.Value)
+	}
+	return
+}
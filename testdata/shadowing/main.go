@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mpppk/gollup/testdata/shadowing/lib"
+)
+
+func describe(lib_Item interface{}) string {
+	switch lib_F1 := lib_Item.(type) {
+	case lib.Item:
+		return fmt.Sprint("item ", lib.F1(lib_F1.Value))
+	case int:
+		return fmt.Sprint("int ", lib_F1)
+	}
+	return "unknown"
+}
+
+func main() {
+	lib_F1 := 3
+	lib_F1_1 := lib.F1(lib_F1)
+	fmt.Println(lib_F1, lib_F1_1, lib.Sum(lib.Item{Value: 4}, lib.Item{Value: 7}))
+	fmt.Println(describe(lib.Item{Value: 2}), describe(5))
+}
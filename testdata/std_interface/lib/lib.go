@@ -0,0 +1,72 @@
+package lib
+
+import "fmt"
+
+type Item struct {
+	Value    int
+	Priority int
+}
+
+type PriorityQueue []*Item
+
+func (pq PriorityQueue) Len() int           { return len(pq) }
+func (pq PriorityQueue) Less(i, j int) bool { return pq[i].Priority > pq[j].Priority }
+func (pq PriorityQueue) Swap(i, j int)      { pq[i], pq[j] = pq[j], pq[i] }
+
+func (pq *PriorityQueue) Push(x interface{}) {
+	*pq = append(*pq, x.(*Item))
+}
+
+func (pq *PriorityQueue) Pop() interface{} {
+	old := *pq
+	n := len(old)
+	item := old[n-1]
+	*pq = old[0 : n-1]
+	return item
+}
+
+type ByLen []string
+
+func (b ByLen) Len() int           { return len(b) }
+func (b ByLen) Less(i, j int) bool { return len(b[i]) < len(b[j]) }
+func (b ByLen) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+type Point struct {
+	X, Y int
+}
+
+func (p Point) String() string {
+	return fmt.Sprintf("(%d, %d)", p.X, p.Y)
+}
+
+func (p Point) Unused() int {
+	return p.X
+}
+
+type NotFoundError struct {
+	Key string
+}
+
+func (e *NotFoundError) Error() string {
+	return "not found: " + e.Key
+}
+
+func Find(key string) error {
+	return &NotFoundError{Key: key}
+}
+
+type Num int
+
+func (n Num) Double() int {
+	return int(n) * 2
+}
+
+func (n Num) String() string {
+	return "num " + fmt.Sprint(int(n))
+}
+
+type Celsius float64
+
+func (c Celsius) String() string {
+	return fmt.Sprintf("%.1f°C", float64(c))
+}
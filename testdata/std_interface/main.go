@@ -0,0 +1,28 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+
+	"github.com/mpppk/gollup/testdata/std_interface/lib"
+)
+
+func main() {
+	pq := &lib.PriorityQueue{}
+	heap.Init(pq)
+	heap.Push(pq, &lib.Item{Value: 1, Priority: 3})
+	heap.Push(pq, &lib.Item{Value: 2, Priority: 5})
+	fmt.Println(heap.Pop(pq).(*lib.Item).Value)
+
+	words := []string{"ccc", "a", "bb"}
+	sort.Sort(lib.ByLen(words))
+	fmt.Println(words)
+
+	fmt.Println(lib.Point{X: 1, Y: 2})
+	fmt.Println(lib.Find("key"))
+
+	fmt.Println("n =", lib.Num(2).Double())
+	temps := []interface{}{lib.Celsius(36.5)}
+	fmt.Println(temps...)
+}
@@ -0,0 +1,13 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/blang/semver"
+)
+
+func main() {
+	v := semver.MustParse("1.2.3-beta.1")
+	w := semver.MustParse("1.2.3")
+	fmt.Println(v.LT(w), v.Major, v.Pre[0])
+}
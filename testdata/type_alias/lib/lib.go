@@ -0,0 +1,27 @@
+package lib
+
+type Int = int64
+
+type point struct {
+	X, Y Int
+}
+
+type Point = point
+
+type Points = []Point
+
+func (p *Point) Scale(k Int) {
+	p.X *= k
+	p.Y *= k
+}
+
+func (p Point) Norm() Int {
+	return p.X*p.X + p.Y*p.Y
+}
+
+func Sum(ps Points) (sum Int) {
+	for _, p := range ps {
+		sum += p.Norm()
+	}
+	return
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mpppk/gollup/testdata/type_alias/lib"
+)
+
+type P = lib.Point
+
+type Named struct {
+	lib.Point
+	Name string
+}
+
+func main() {
+	p := P{X: 1, Y: 2}
+	p.Scale(2)
+	n := Named{Point: p, Name: "n"}
+	n.Point.X++
+	fmt.Println(p.Norm(), n.Norm(), lib.Sum(lib.Points{p, {X: 3}}))
+}
@@ -0,0 +1,48 @@
+package lib
+
+const MaxN = 4
+
+type (
+	Node   int
+	Weight int64
+	Cost   float64
+	Edge   struct {
+		To     Node
+		Weight Weight
+	}
+	Event  struct{ Kind string }
+	Result struct{ Found bool }
+	Item   struct{ Value Node }
+	Heap   []Item
+	Unused struct{ U UnusedField }
+)
+
+type UnusedField struct{}
+
+type Visitor interface {
+	Visit(n Node) Result
+}
+
+type Graph struct {
+	edges   [][]Edge
+	degrees map[Node]Weight
+	events  chan Event
+	cost    func(Node) Cost
+	visited [MaxN]bool
+	visitor Visitor
+	heap    Heap
+}
+
+func NewGraph() *Graph {
+	g := &Graph{}
+	g.edges = make([][]Edge, MaxN)
+	return g
+}
+
+func (g *Graph) AddEdge(from, to int, w int) {
+	g.edges[from] = append(g.edges[from], Edge{To: Node(to), Weight: Weight(w)})
+}
+
+func (g *Graph) Len() int {
+	return len(g.edges) + len(g.visited) + len(g.heap)
+}
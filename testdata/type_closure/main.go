@@ -0,0 +1,13 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mpppk/gollup/testdata/type_closure/lib"
+)
+
+func main() {
+	g := lib.NewGraph()
+	g.AddEdge(0, 1, 3)
+	fmt.Println(g.Len())
+}
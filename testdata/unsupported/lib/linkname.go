@@ -0,0 +1,11 @@
+package lib
+
+import _ "unsafe"
+
+//go:linkname nanotime runtime.nanotime
+func nanotime() int64
+
+// Now returns the monotonic time
+func Now() int64 {
+	return nanotime()
+}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mpppk/gollup/testdata/unsupported/lib"
+)
+
+func main() {
+	fmt.Println(lib.Add(1, 2), lib.Now() > 0)
+}
@@ -0,0 +1,11 @@
+package lib
+
+/*
+static int twice(int v) { return v * 2; }
+*/
+import "C"
+
+// Twice doubles v in C
+func Twice(v int) int {
+	return int(C.twice(C.int(v)))
+}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mpppk/gollup/testdata/unsupported_cgo/lib"
+)
+
+func main() {
+	fmt.Println(lib.Twice(3))
+}
@@ -2,6 +2,8 @@ package util
 
 import (
 	"go/types"
+	"regexp"
+	"strings"
 
 	"golang.org/x/tools/go/packages"
 )
@@ -27,3 +29,14 @@ func IsStandardPackage(pkg string) bool {
 	_, ok := standardPackages[pkg]
 	return ok
 }
+
+// MatchPackagePattern reports whether the import path matches the pattern.
+// As in go list, "..." matches any string and "foo/..." also matches "foo" itself.
+func MatchPackagePattern(pattern, path string) bool {
+	re := regexp.QuoteMeta(pattern)
+	re = strings.ReplaceAll(re, `\.\.\.`, `.*`)
+	if strings.HasSuffix(re, `/.*`) {
+		re = strings.TrimSuffix(re, `/.*`) + `(/.*)?`
+	}
+	return regexp.MustCompile(`^` + re + `$`).MatchString(path)
+}
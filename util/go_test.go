@@ -0,0 +1,30 @@
+package util_test
+
+import (
+	"testing"
+
+	"github.com/mpppk/gollup/util"
+)
+
+func TestMatchPackagePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{pattern: "github.com/me/lib", path: "github.com/me/lib", want: true},
+		{pattern: "github.com/me/lib", path: "github.com/me/lib/sub", want: false},
+		{pattern: "github.com/me/lib/...", path: "github.com/me/lib", want: true},
+		{pattern: "github.com/me/lib/...", path: "github.com/me/lib/sub/deep", want: true},
+		{pattern: "github.com/me/lib/...", path: "github.com/me/library", want: false},
+		{pattern: "github.com/.../bitset", path: "github.com/foo/bar/bitset", want: true},
+		{pattern: "github.com/me/lib.v2", path: "github.com/me/libxv2", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			if got := util.MatchPackagePattern(tt.pattern, tt.path); got != tt.want {
+				t.Errorf("MatchPackagePattern() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}